@@ -0,0 +1,53 @@
+// Command circom-export writes a main component along with
+// its circuit pkgs as a single self-contained .circom file
+//
+//	go run ./cmd/circom-export -o privacypool.circom
+//	go run ./cmd/circom-export -main "component main = Ecdh();" -pkgs ecdh.EcdhCircuitPkg
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	"github.com/0xBow-io/privacy-pool-veritas/export"
+
+	. "github.com/0xBow-io/veritas"
+)
+
+const defaultMain = "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, newSaltPublicKey, newCiphertext]} = PrivacyPool(32, 7, 4, 2, 2);"
+
+func main() {
+	var (
+		mainSrc = flag.String("main", defaultMain, "main component declaration")
+		pkgs    = flag.String("pkgs", "privacypool.PrivacyPoolCircuitPkg", "comma separated circuit pkgs to resolve")
+		out     = flag.String("o", "", "output file (default stdout)")
+	)
+	flag.Parse()
+
+	if err := run(*mainSrc, strings.Split(*pkgs, ","), *out); err != nil {
+		fmt.Fprintln(os.Stderr, "circom-export:", err)
+		os.Exit(1)
+	}
+}
+
+func run(mainSrc string, names []string, out string) error {
+	pkgs, err := privacypool.Resolve(names...)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return export.Circom(w, Program{Identity: "main", Src: mainSrc}, pkgs...)
+}
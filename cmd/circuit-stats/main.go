@@ -0,0 +1,123 @@
+// Command circuit-stats reports the constraint & signal counts
+// of a template instantiation, broken down per sub-component,
+// or checks the circuits of a baseline file for regressions
+//
+//	go run ./cmd/circuit-stats -template PrivacyPool -params 32,7,4,2,2 -public scope,context
+//	go run ./cmd/circuit-stats -baseline stats/baseline.json [-update]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	"github.com/0xBow-io/privacy-pool-veritas/stats"
+)
+
+func main() {
+	var (
+		template = flag.String("template", "", "template to measure")
+		params   = flag.String("params", "", "comma separated template parameters")
+		public   = flag.String("public", "", "comma separated public input signals")
+		depth    = flag.Int("depth", 1, "sub-component breakdown depth")
+		baseline = flag.String("baseline", "", "baseline file to check")
+		update   = flag.Bool("update", false, "rewrite the baseline with the current counts")
+	)
+	flag.Parse()
+
+	var err error
+	switch {
+	case *template != "":
+		err = measure(*template, *params, *public, *depth)
+	case *baseline != "":
+		err = check(*baseline, *update)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "circuit-stats:", err)
+		os.Exit(1)
+	}
+}
+
+func measure(template, params, public string, depth int) error {
+	c := stats.Circuit{Template: template}
+	for _, p := range split(params) {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return fmt.Errorf("invalid param %q: %w", p, err)
+		}
+		c.Params = append(c.Params, v)
+	}
+	c.Public = split(public)
+
+	pkgs, err := privacypool.Bundle()
+	if err != nil {
+		return err
+	}
+	m, err := stats.Measure(c, depth, pkgs...)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t\n", c)
+	fmt.Fprintf(w, "constraints\t%d\t\n", m.Constraints)
+	fmt.Fprintf(w, "public inputs\t%d\t\n", m.PublicInputs)
+	fmt.Fprintf(w, "private inputs\t%d\t\n", m.PrivateInputs)
+	fmt.Fprintf(w, "outputs\t%d\t\n", m.Outputs)
+	fmt.Fprintf(w, "intermediates\t%d\t\n", m.Intermediates)
+	if len(m.Components) > 0 {
+		fmt.Fprintf(w, "\t\n")
+		fmt.Fprintf(w, "component\tinstances\tconstraints\tsignals\t\n")
+		for _, comp := range m.Components {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t\n", comp.Name, comp.Instances, comp.Constraints, comp.Signals)
+		}
+	}
+	return w.Flush()
+}
+
+func check(path string, update bool) error {
+	baseline, err := stats.LoadBaseline(path)
+	if err != nil {
+		return err
+	}
+	pkgs, err := privacypool.Bundle()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, entry := range baseline {
+		m, err := stats.Measure(entry.Circuit, 0, pkgs...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d constraints (baseline %d)\n", entry.Circuit, m.Constraints, entry.Constraints)
+		if update {
+			baseline[i].Counts = m.Counts
+		} else if err := entry.Compare(m.Counts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if update {
+		return baseline.Save(path)
+	}
+	return errors.Join(errs...)
+}
+
+func split(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+// Command public-signals prints the public signal layout
+// (names, indices, shapes & Solidity ABI types) of PrivacyPool
+// for a parameter set as JSON
+//
+//	go run ./cmd/public-signals -params 32,7,4,2,2
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+)
+
+func main() {
+	params := flag.String("params", "32,7,4,2,2", "maxTreeDepth,cipherLen,tupleLen,nExisting,nNew")
+	flag.Parse()
+
+	if err := run(*params); err != nil {
+		fmt.Fprintln(os.Stderr, "public-signals:", err)
+		os.Exit(1)
+	}
+}
+
+func run(params string) error {
+	var values []int
+	for _, p := range strings.Split(params, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return fmt.Errorf("invalid param %q: %w", p, err)
+		}
+		values = append(values, v)
+	}
+	if len(values) != 5 {
+		return fmt.Errorf("expected 5 params, got %d", len(values))
+	}
+	p := privacypool.PrivacyPoolParams{
+		MaxTreeDepth: values[0],
+		CipherLen:    values[1],
+		TupleLen:     values[2],
+		NExisting:    values[3],
+		NNew:         values[4],
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Instance string                     `json:"instance"`
+		Length   int                        `json:"length"`
+		Signals  []privacypool.SignalLayout `json:"signals"`
+	}{p.Instance(), p.PublicSignalsLength(), p.PublicSignalLayout()})
+}
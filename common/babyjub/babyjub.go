@@ -3,6 +3,8 @@ package babyjub
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -18,9 +20,18 @@ var BabyJubCircuitPkg = CircuitPkg{
 		BabyDbl,
 		BabyCheck,
 		BabyPrivToPubKey,
+		BabySubGroupHint,
+		BabySubGroupCheck,
 	},
 }
 
+func init() {
+	registry.Register("babyjub.BabyJubCircuitPkg", BabyJubCircuitPkg,
+		"bit.BitifyCircuitPkg",
+		"scalar.EscalarMulCircuitPkg",
+	)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 var (
@@ -127,3 +138,66 @@ var (
         }
     `}
 )
+
+var (
+	// babySubGroupHint returns q = (8^-1 mod l) * p
+	// computed with BabyAdd's formulas, only used as a witness hint
+	BabySubGroupHint = Program{
+		Identity: "babySubGroupHint",
+		Src: `
+		function babySubGroupHint(x, y) {
+            var a = 168700;
+            var d = 168696;
+
+            // 8^-1 mod l
+            var inv8 = 2394026564107420727433200628387514462817212225638746351800188703329891451411;
+
+            var r[2] = [0, 1];
+            var q[2] = [x, y];
+            var tau;
+            var rx;
+            for (var i = 0; i < 251; i++) {
+                if (((inv8 >> i) & 1) == 1) {
+                    tau = d*r[0]*q[0]*r[1]*q[1];
+                    rx = (r[0]*q[1] + r[1]*q[0]) / (1 + tau);
+                    r[1] = (r[1]*q[1] - a*r[0]*q[0]) / (1 - tau);
+                    r[0] = rx;
+                }
+                tau = d*q[0]*q[0]*q[1]*q[1];
+                rx = (2*q[0]*q[1]) / (1 + tau);
+                q[1] = (q[1]*q[1] - a*q[0]*q[0]) / (1 - tau);
+                q[0] = rx;
+            }
+            return r;
+        }
+	`}
+
+	// BabySubGroupCheck constrains p to the prime subgroup
+	// by proving that p = 8*q for some point q on the curve,
+	// which rules out the low order (torsion) components of p
+	BabySubGroupCheck = Program{
+		Identity: "BabySubGroupCheck",
+		Src: `
+		template BabySubGroupCheck() {
+            input signal p[2];
+
+            signal q[2];
+            var hint[2] = babySubGroupHint(p[0], p[1]);
+            q[0] <-- hint[0];
+            q[1] <-- hint[1];
+            BabyCheck()(q[0], q[1]);
+
+            component dbl[3];
+            dbl[0] = BabyDbl();
+            dbl[0].x <== q[0];
+            dbl[0].y <== q[1];
+            for (var i = 1; i < 3; i++) {
+                dbl[i] = BabyDbl();
+                dbl[i].x <== dbl[i-1].xout;
+                dbl[i].y <== dbl[i-1].yout;
+            }
+            p[0] === dbl[2].xout;
+            p[1] === dbl[2].yout;
+        }
+	`}
+)
@@ -3,6 +3,8 @@ package babyjub
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -19,6 +21,10 @@ var MontGomeryCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("babyjub.MontGomeryCircuitPkg", MontGomeryCircuitPkg)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 var (
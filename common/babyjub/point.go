@@ -0,0 +1,142 @@
+package babyjub
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+)
+
+// Native (Go) BabyJubJub curve arithmetic
+// matching the BabyAdd, BabyCheck & BabyPrivToPubKey templates:
+//
+//	a*x^2 + y^2 = 1 + d*x^2*y^2  over the bn128 scalar field
+
+var (
+	A = big.NewInt(168700)
+	D = big.NewInt(168696)
+
+	// SubOrder is the prime subgroup order 'l'
+	SubOrder, _ = new(big.Int).SetString(
+		"2736030358979909402780800718157159386076813972158567259200215660948447373041", 10,
+	)
+
+	// Base8 is the base point of the prime subgroup
+	Base8 = &Point{
+		X: bigFromString("5299619240641551281634865583518297030282874472190772894086521144482721001553"),
+		Y: bigFromString("16950150798460657717958625567821834550301663161624707787222815936182638968203"),
+	}
+
+	ErrInvalidPrivateKey  = errors.New("private key must be within [0, SubOrder)")
+	ErrPointNotOnCurve    = errors.New("point is not on the BabyJubJub curve")
+	ErrPointNotInSubGroup = errors.New("point is not in the BabyJubJub prime subgroup")
+)
+
+// Point is a point on the BabyJubJub curve in twisted Edwards form
+type Point struct {
+	X *big.Int `json:"x"`
+	Y *big.Int `json:"y"`
+}
+
+// Identity returns the neutral element (0, 1)
+func Identity() *Point {
+	return &Point{X: big.NewInt(0), Y: big.NewInt(1)}
+}
+
+// Equal reports whether p and q are the same point
+func (p *Point) Equal(q *Point) bool {
+	return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// InCurve mirrors BabyCheck
+func (p *Point) InCurve() bool {
+	if p == nil || !field.IsCanonical(p.X) || !field.IsCanonical(p.Y) {
+		return false
+	}
+	var (
+		x2  = new(big.Int).Mul(p.X, p.X)
+		y2  = new(big.Int).Mul(p.Y, p.Y)
+		lhs = new(big.Int).Mul(A, x2)
+		rhs = new(big.Int).Mul(D, x2)
+	)
+	lhs.Add(lhs, y2)
+	rhs.Mul(rhs, y2)
+	rhs.Add(rhs, big.NewInt(1))
+	return field.Reduce(lhs).Cmp(field.Reduce(rhs)) == 0
+}
+
+// InSubGroup mirrors BabySubGroupCheck:
+// p is on the curve & l*p is the identity
+func (p *Point) InSubGroup() bool {
+	if !p.InCurve() {
+		return false
+	}
+	// Mul maps any point with a zero x-coordinate to the identity,
+	// (0, -1) has order 2
+	if p.X.Sign() == 0 {
+		return p.Y.Cmp(big.NewInt(1)) == 0
+	}
+	return p.Mul(SubOrder).Equal(Identity())
+}
+
+// Add mirrors BabyAdd
+func (p *Point) Add(q *Point) *Point {
+	var (
+		beta  = field.Reduce(new(big.Int).Mul(p.X, q.Y))
+		gamma = field.Reduce(new(big.Int).Mul(p.Y, q.X))
+		// delta = (-a*x1 + y1) * (x2 + y2)
+		delta = new(big.Int).Mul(
+			new(big.Int).Sub(p.Y, new(big.Int).Mul(A, p.X)),
+			new(big.Int).Add(q.X, q.Y),
+		)
+		dtau = field.Reduce(new(big.Int).Mul(D, new(big.Int).Mul(beta, gamma)))
+	)
+	// xout = (beta + gamma) / (1 + d*tau)
+	x := new(big.Int).Add(beta, gamma)
+	x.Mul(x, inverse(new(big.Int).Add(big.NewInt(1), dtau)))
+
+	// yout = (delta + a*beta - gamma) / (1 - d*tau)
+	y := new(big.Int).Add(delta, new(big.Int).Mul(A, beta))
+	y.Sub(y, gamma)
+	y.Mul(y, inverse(new(big.Int).Sub(big.NewInt(1), dtau)))
+
+	return &Point{X: field.Reduce(x), Y: field.Reduce(y)}
+}
+
+// Mul returns e * p using double & add
+// A point with a zero x-coordinate yields the identity,
+// as with EscalarMulAny
+func (p *Point) Mul(e *big.Int) *Point {
+	var (
+		r = Identity()
+		q = &Point{X: new(big.Int).Set(p.X), Y: new(big.Int).Set(p.Y)}
+	)
+	if p.X.Sign() == 0 {
+		return r
+	}
+	for i := 0; i < e.BitLen(); i++ {
+		if e.Bit(i) == 1 {
+			r = r.Add(q)
+		}
+		q = q.Add(q)
+	}
+	return r
+}
+
+// PrivToPub mirrors BabyPrivToPubKey: privKey * Base8
+// where privKey has to be within the prime subgroup order
+func PrivToPub(privKey *big.Int) (*Point, error) {
+	if privKey == nil || privKey.Sign() < 0 || privKey.Cmp(SubOrder) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	return Base8.Mul(privKey), nil
+}
+
+func inverse(x *big.Int) *big.Int {
+	return new(big.Int).ModInverse(field.Reduce(x), field.Modulus)
+}
+
+func bigFromString(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
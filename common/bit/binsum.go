@@ -3,6 +3,8 @@ package bit
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -17,6 +19,10 @@ var BinSumCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("bit.BinSumCircuitPkg", BinSumCircuitPkg)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 // circuits/binsum.circom
@@ -3,6 +3,8 @@ package bit
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -21,6 +23,13 @@ var BitifyCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("bit.BitifyCircuitPkg", BitifyCircuitPkg,
+		"utils.CircuitUtilsPkg",
+		"comparators.ComparatorsCircuitPkg",
+	)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 // circuits/bitify.circom
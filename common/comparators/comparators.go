@@ -3,6 +3,8 @@ package comparators
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -23,6 +25,13 @@ var ComparatorsCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("comparators.ComparatorsCircuitPkg", ComparatorsCircuitPkg,
+		"bit.BitifyCircuitPkg",
+		"bit.BinSumCircuitPkg",
+	)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 // circuits/comparators.circom
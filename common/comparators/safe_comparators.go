@@ -3,12 +3,14 @@ package comparators
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
 // SafeComparators is the package containg all
 // circuit blocks to support safe comparison operations
-// @NOTICE: Bitfiy pkg is required but not included here
+// @NOTICE: Bitify pkg is required but not included here
 var SafeComparatorsCircuitPkg = CircuitPkg{
 	TargetVersion: "2.2.0",
 	Field:         "bn128",
@@ -20,6 +22,12 @@ var SafeComparatorsCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("comparators.SafeComparatorsCircuitPkg", SafeComparatorsCircuitPkg,
+		"bit.BitifyCircuitPkg",
+	)
+}
+
 // Below Implementations are from zk-kit.circom
 // See: https://github.com/privacy-scaling-explorations/zk-kit.circom
 // packages/utils/src/safe-comparators.circom
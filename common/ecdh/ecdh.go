@@ -3,11 +3,13 @@ package ecdh
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
 // EcdhCircuitPkg is the package containing the ecdh template
-// @NOTICE: escalarmulany pkg is required but not included here
+// @NOTICE: Bitify & escalarmulany pkgs are required but not included here
 var EcdhCircuitPkg = CircuitPkg{
 	TargetVersion: "2.2.0",
 	Field:         "bn128",
@@ -16,6 +18,13 @@ var EcdhCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("ecdh.EcdhCircuitPkg", EcdhCircuitPkg,
+		"bit.BitifyCircuitPkg",
+		"scalar.EscalarMulCircuitPkg",
+	)
+}
+
 // Below Implementations are from zk-kit.circom
 // See: https://github.com/privacy-scaling-explorations/zk-kit.circom
 // packages/ecdh/src/ecdh.circom
@@ -0,0 +1,22 @@
+package ecdh
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+)
+
+var ErrInvalidPrivateKey = errors.New("private key must fit within 253 bits")
+
+// SharedKey is the native (Go) counterpart of the Ecdh template:
+// privateKey * publicKey, with privateKey decomposed into 253 bits
+func SharedKey(privateKey *big.Int, publicKey *babyjub.Point) (*babyjub.Point, error) {
+	if privateKey == nil || privateKey.Sign() < 0 || privateKey.BitLen() > 253 {
+		return nil, ErrInvalidPrivateKey
+	}
+	if !publicKey.InCurve() {
+		return nil, babyjub.ErrPointNotOnCurve
+	}
+	return publicKey.Mul(privateKey), nil
+}
@@ -0,0 +1,49 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Modulus is the order of the bn128 (BN254) scalar field,
+// the prime field every circuit in this module is defined over.
+var Modulus, _ = new(big.Int).SetString(
+	"21888242871839275222246405745257275088548364400416034343698204186575808495617", 10,
+)
+
+var ErrNotInField = errors.New("value is not a canonical field element")
+
+// Reduce returns x mod Modulus as a new value
+// negative values are mapped to their field representative
+func Reduce(x *big.Int) *big.Int {
+	return new(big.Int).Mod(x, Modulus)
+}
+
+// IsCanonical reports whether 0 <= x < Modulus
+func IsCanonical(x *big.Int) bool {
+	return x != nil && x.Sign() >= 0 && x.Cmp(Modulus) < 0
+}
+
+// FromString parses a decimal or 0x-prefixed hexadecimal
+// string into a canonical field element
+func FromString(s string) (*big.Int, error) {
+	var (
+		v  *big.Int
+		ok bool
+		in = strings.TrimSpace(s)
+	)
+	if strings.HasPrefix(in, "0x") || strings.HasPrefix(in, "0X") {
+		v, ok = new(big.Int).SetString(in[2:], 16)
+	} else {
+		v, ok = new(big.Int).SetString(in, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid field element %q", s)
+	}
+	if !IsCanonical(v) {
+		return nil, fmt.Errorf("%w: %s", ErrNotInField, s)
+	}
+	return v, nil
+}
@@ -3,6 +3,8 @@ package logic
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -21,6 +23,10 @@ var GatesCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("logic.GatesCircuitPkg", GatesCircuitPkg)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 var (
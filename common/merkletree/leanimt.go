@@ -0,0 +1,214 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+)
+
+// Native (Go) Lean Incremental Merkle Tree (LeanIMT)
+// Based on the zk-kit implementation:
+// https://github.com/privacy-scaling-explorations/zk-kit/tree/main/packages/lean-imt
+//
+// Nodes without a right sibling are not hashed but propagated
+// to the next level as is, so the depth of the tree is always
+// ceil(log2(size)) and proofs only contain existing siblings.
+
+var (
+	ErrEmptyTree        = errors.New("tree is empty")
+	ErrIndexOutOfRange  = errors.New("leaf index is out of range")
+	ErrMaxDepthExceeded = errors.New("tree depth exceeds the maximum depth")
+	ErrInvalidLeaf      = errors.New("leaf must not be nil")
+)
+
+// LeanIMT keeps every node of the tree in memory
+// nodes[0] are the leaves and nodes[depth][0] is the root
+type LeanIMT struct {
+	nodes [][]*big.Int
+}
+
+// LeanIMTProof contains the witness values
+// expected by LeanIMTInclusionProof(maxDepth)
+type LeanIMTProof struct {
+	Root *big.Int `json:"root"`
+	Leaf *big.Int `json:"leaf"`
+	// LeafIndex is the path of the leaf through the
+	// levels that have a sibling, i.e. the "leafIndex" signal
+	LeafIndex int `json:"leafIndex"`
+	// Siblings zero padded to maxDepth
+	Siblings []*big.Int `json:"siblings"`
+	// ActualDepth is the number of non-padded siblings
+	ActualDepth int `json:"actualDepth"`
+}
+
+func NewLeanIMT() *LeanIMT {
+	return &LeanIMT{nodes: [][]*big.Int{{}}}
+}
+
+// Size is the number of leaves
+func (t *LeanIMT) Size() int { return len(t.nodes[0]) }
+
+// Depth is the number of levels above the leaves
+func (t *LeanIMT) Depth() int { return len(t.nodes) - 1 }
+
+// Root returns nil if the tree is empty
+func (t *LeanIMT) Root() *big.Int {
+	if t.Size() == 0 {
+		return nil
+	}
+	return t.nodes[t.Depth()][0]
+}
+
+func (t *LeanIMT) Leaves() []*big.Int {
+	return append([]*big.Int{}, t.nodes[0]...)
+}
+
+// IndexOf returns the index of the first matching leaf or -1
+func (t *LeanIMT) IndexOf(leaf *big.Int) int {
+	for i, l := range t.nodes[0] {
+		if l.Cmp(leaf) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert appends a leaf to the tree
+func (t *LeanIMT) Insert(leaf *big.Int) error {
+	return t.InsertMany([]*big.Int{leaf})
+}
+
+// InsertMany appends leaves to the tree,
+// recomputing each affected node only once
+func (t *LeanIMT) InsertMany(leaves []*big.Int) error {
+	for _, leaf := range leaves {
+		if leaf == nil {
+			return ErrInvalidLeaf
+		}
+	}
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	start := t.Size()
+	t.nodes[0] = append(t.nodes[0], leaves...)
+	for depth := 0; (1 << depth) < t.Size(); depth++ {
+		if len(t.nodes) <= depth+1 {
+			t.nodes = append(t.nodes, []*big.Int{})
+		}
+	}
+	for level := 0; level < t.Depth(); level++ {
+		var (
+			size   = (len(t.nodes[level]) + 1) / 2
+			parent = t.nodes[level+1]
+		)
+		for len(parent) < size {
+			parent = append(parent, nil)
+		}
+		for i := start >> (level + 1); i < size; i++ {
+			node, err := t.parentOf(level, i*2)
+			if err != nil {
+				return err
+			}
+			parent[i] = node
+		}
+		t.nodes[level+1] = parent
+	}
+	return nil
+}
+
+// Update replaces the leaf at index
+func (t *LeanIMT) Update(index int, leaf *big.Int) error {
+	if leaf == nil {
+		return ErrInvalidLeaf
+	}
+	if index < 0 || index >= t.Size() {
+		return ErrIndexOutOfRange
+	}
+	t.nodes[0][index] = leaf
+	for level := 0; level < t.Depth(); level++ {
+		index >>= 1
+		node, err := t.parentOf(level, index*2)
+		if err != nil {
+			return err
+		}
+		t.nodes[level+1][index] = node
+	}
+	return nil
+}
+
+// GenerateProof returns the inclusion proof of the leaf at index
+// with the siblings zero padded to maxDepth
+func (t *LeanIMT) GenerateProof(index, maxDepth int) (*LeanIMTProof, error) {
+	if t.Size() == 0 {
+		return nil, ErrEmptyTree
+	}
+	if index < 0 || index >= t.Size() {
+		return nil, ErrIndexOutOfRange
+	}
+	if t.Depth() > maxDepth {
+		return nil, fmt.Errorf("%w: %d > %d", ErrMaxDepthExceeded, t.Depth(), maxDepth)
+	}
+
+	var (
+		leaf      = t.nodes[0][index]
+		siblings  = make([]*big.Int, 0, maxDepth)
+		leafIndex = 0
+	)
+	for level := 0; level < t.Depth(); level++ {
+		var (
+			isRight = index & 1
+			sibling = index ^ 1
+		)
+		if sibling < len(t.nodes[level]) {
+			leafIndex |= isRight << len(siblings)
+			siblings = append(siblings, t.nodes[level][sibling])
+		}
+		index >>= 1
+	}
+
+	actualDepth := len(siblings)
+	for len(siblings) < maxDepth {
+		siblings = append(siblings, big.NewInt(0))
+	}
+	return &LeanIMTProof{
+		Root:        t.Root(),
+		Leaf:        leaf,
+		LeafIndex:   leafIndex,
+		Siblings:    siblings,
+		ActualDepth: actualDepth,
+	}, nil
+}
+
+// VerifyProof recomputes the root from the proof
+// the same way LeanIMTInclusionProof does
+func VerifyProof(proof *LeanIMTProof) (bool, error) {
+	if proof == nil || proof.Leaf == nil || proof.Root == nil || proof.ActualDepth > len(proof.Siblings) {
+		return false, nil
+	}
+	node := proof.Leaf
+	for i := 0; i < proof.ActualDepth; i++ {
+		var (
+			children = []*big.Int{node, proof.Siblings[i]}
+			err      error
+		)
+		if (proof.LeafIndex>>i)&1 == 1 {
+			children = []*big.Int{proof.Siblings[i], node}
+		}
+		if node, err = poseidon.Hash(children); err != nil {
+			return false, err
+		}
+	}
+	return node.Cmp(proof.Root) == 0, nil
+}
+
+// parentOf computes the parent of the left node at nodes[level][left]
+// a node without a right sibling is propagated as is
+func (t *LeanIMT) parentOf(level, left int) (*big.Int, error) {
+	if left+1 >= len(t.nodes[level]) {
+		return t.nodes[level][left], nil
+	}
+	return poseidon.Hash([]*big.Int{t.nodes[level][left], t.nodes[level][left+1]})
+}
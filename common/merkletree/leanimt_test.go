@@ -0,0 +1,126 @@
+package merkletree
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/bit"
+	"github.com/0xBow-io/privacy-pool-veritas/common/comparators"
+	"github.com/0xBow-io/privacy-pool-veritas/common/multiplexer"
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+	"github.com/0xBow-io/privacy-pool-veritas/common/utils"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+func leavesOf(n int) []*big.Int {
+	leaves := make([]*big.Int, n)
+	for i := range leaves {
+		leaves[i] = big.NewInt(int64(i + 1))
+	}
+	return leaves
+}
+
+func Test_LeanIMT(t *testing.T) {
+	tree := NewLeanIMT()
+	require.Nil(t, tree.Root())
+	_, err := tree.GenerateProof(0, 4)
+	require.Equal(t, ErrEmptyTree, err)
+
+	for n := 1; n <= 33; n++ {
+		require.Nil(t, tree.Insert(big.NewInt(int64(n))))
+
+		batch := NewLeanIMT()
+		require.Nil(t, batch.InsertMany(leavesOf(n)))
+		require.Equal(t, tree.Root(), batch.Root(), "size %d", n)
+
+		// depth = ceil(log2(size))
+		depth := 0
+		for (1 << depth) < n {
+			depth++
+		}
+		require.Equal(t, depth, tree.Depth())
+
+		for i := 0; i < n; i++ {
+			proof, err := tree.GenerateProof(i, 8)
+			require.Nil(t, err)
+			require.Len(t, proof.Siblings, 8)
+			ok, err := VerifyProof(proof)
+			require.Nil(t, err)
+			require.True(t, ok, "size %d index %d", n, i)
+		}
+	}
+
+	// incremental batches
+	batch := NewLeanIMT()
+	require.Nil(t, batch.InsertMany(leavesOf(5)))
+	require.Nil(t, batch.InsertMany(leavesOf(33)[5:]))
+	require.Equal(t, tree.Root(), batch.Root())
+
+	// updating a leaf matches rebuilding the tree
+	leaves := leavesOf(33)
+	leaves[17] = big.NewInt(1000)
+	rebuilt := NewLeanIMT()
+	require.Nil(t, rebuilt.InsertMany(leaves))
+	require.Nil(t, tree.Update(17, big.NewInt(1000)))
+	require.Equal(t, rebuilt.Root(), tree.Root())
+	require.Equal(t, 17, tree.IndexOf(big.NewInt(1000)))
+
+	require.Equal(t, ErrIndexOutOfRange, tree.Update(33, big.NewInt(1)))
+	_, err = tree.GenerateProof(0, 5)
+	require.NotNil(t, err)
+}
+
+// Evaluate LeanIMTInclusionProof against proofs
+// for every tree depth from 0 to maxDepth
+func Test_LeanIMT_Circuit(t *testing.T) {
+	const maxDepth = 4
+	var lib = NewEmptyLibrary()
+	defer lib.Burn()
+
+	reports, err := lib.Compile(
+		CircuitPkg{
+			TargetVersion: "2.2.0",
+			Field:         "bn128",
+			Programs: []Program{
+				{
+					Identity: "main",
+					Src:      fmt.Sprintf("component main {public[leaf]} = LeanIMTInclusionProof(%d);", maxDepth),
+				},
+			},
+		},
+		MerkleTreeCircuitPkg,
+		poseidon.PoseidonCircuitPkg,
+		bit.BitifyCircuitPkg,
+		comparators.ComparatorsCircuitPkg,
+		comparators.SafeComparatorsCircuitPkg,
+		multiplexer.MultiplexerCircuitPkg,
+		utils.CircuitUtilsPkg,
+	)
+	require.Nil(t, err)
+	require.False(t, harness.HasErrors(reports), reports.String())
+
+	tree := NewLeanIMT()
+	for n := 1; n <= 1<<maxDepth; n++ {
+		require.Nil(t, tree.Insert(big.NewInt(int64(n*7))))
+		for i := 0; i < n; i++ {
+			proof, err := tree.GenerateProof(i, maxDepth)
+			require.Nil(t, err)
+
+			siblings := make([]string, len(proof.Siblings))
+			for j, s := range proof.Siblings {
+				siblings[j] = `"` + s.String() + `"`
+			}
+			evaluation, err := lib.Evaluate([]byte(fmt.Sprintf(
+				`{"leaf":"%s","leafIndex":"%d","siblings":[%s],"actualDepth":"%d"}`,
+				proof.Leaf, proof.LeafIndex, strings.Join(siblings, ","), proof.ActualDepth,
+			)))
+			require.Nil(t, err)
+			require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+			require.Equal(t, proof.Root, harness.Signal(t, evaluation, "main.out"), "size %d index %d", n, i)
+		}
+	}
+}
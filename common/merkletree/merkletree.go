@@ -3,6 +3,8 @@ package merkletree
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -25,6 +27,16 @@ var MerkleTreeCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("merkletree.MerkleTreeCircuitPkg", MerkleTreeCircuitPkg,
+		"bit.BitifyCircuitPkg",
+		"poseidon.PoseidonCircuitPkg",
+		"multiplexer.MultiplexerCircuitPkg",
+		"comparators.SafeComparatorsCircuitPkg",
+		"utils.CircuitUtilsPkg",
+	)
+}
+
 // Program below are dependent on Poseidon hash function
 // Will need to import PoseidonCircuitPkg.
 var (
@@ -0,0 +1,33 @@
+package merkletree
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+)
+
+var ErrInvalidLeaves = errors.New("number of leaves must be a power of 2 (>= 2)")
+
+// ComputeRoot is the native (Go) counterpart of ComputeMerkleTreeRoot(levels)
+// where len(leaves) == 2**levels
+func ComputeRoot(leaves []*big.Int) (*big.Int, error) {
+	n := len(leaves)
+	if n < 2 || n&(n-1) != 0 {
+		return nil, ErrInvalidLeaves
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		next := make([]*big.Int, len(level)/2)
+		for i := range next {
+			hash, err := poseidon.Hash([]*big.Int{level[i*2], level[i*2+1]})
+			if err != nil {
+				return nil, err
+			}
+			next[i] = hash
+		}
+		level = next
+	}
+	return level[0], nil
+}
@@ -0,0 +1,164 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// roundConstants holds the optimized Poseidon
+// constants (c, s, m, p) for a single state width t
+// as declared in constants.circom
+type roundConstants struct {
+	t        int
+	nRoundsF int
+	nRoundsP int
+	c        []*big.Int
+	s        []*big.Int
+	m        [][]*big.Int
+	p        [][]*big.Int
+}
+
+var (
+	reWidth     = regexp.MustCompile(`t\s*==\s*(\d+)`)
+	reHex       = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	reRoundsF   = regexp.MustCompile(`function\s+N_ROUNDS_F\(\)\s*\{\s*return\s+(\d+)\s*;`)
+	reRoundsP   = regexp.MustCompile(`var\s+N_ROUNDS_P\[\d+\]\s*=\s*\[([^\]]*)\]`)
+	loadOnce    sync.Once
+	loadErr     error
+	constantsOf map[int]*roundConstants
+)
+
+// loadConstants parses the Circom sources of
+// POSEIDON_STATIC_CONSTANTS & STATIC_ROUND_CONSTANTS
+// so that the native hasher is driven by the exact
+// same tables as the circuit
+func loadConstants() (map[int]*roundConstants, error) {
+	loadOnce.Do(func() {
+		constantsOf, loadErr = parseConstants(POSEIDON_STATIC_CONSTANTS.Src, STATIC_ROUND_CONSTANTS.Src)
+	})
+	return constantsOf, loadErr
+}
+
+func parseConstants(static, rounds string) (map[int]*roundConstants, error) {
+	nRoundsF, nRoundsP, err := parseRounds(rounds)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]map[int][]*big.Int, 4)
+	for _, name := range []string{"POSEIDON_C", "POSEIDON_S", "POSEIDON_M", "POSEIDON_P"} {
+		if tables[name], err = parseFunctionTable(static, name); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(map[int]*roundConstants, len(nRoundsP))
+	for t, rp := range nRoundsP {
+		var (
+			c, cOk = tables["POSEIDON_C"][t]
+			s, sOk = tables["POSEIDON_S"][t]
+			m, mOk = tables["POSEIDON_M"][t]
+			p, pOk = tables["POSEIDON_P"][t]
+		)
+		if !cOk || !sOk || !mOk || !pOk {
+			return nil, fmt.Errorf("poseidon: missing constants for t=%d", t)
+		}
+		if len(c) != t*nRoundsF+rp {
+			return nil, fmt.Errorf("poseidon: POSEIDON_C(%d) has %d elements, expected %d", t, len(c), t*nRoundsF+rp)
+		}
+		if len(s) != rp*(t*2-1) {
+			return nil, fmt.Errorf("poseidon: POSEIDON_S(%d) has %d elements, expected %d", t, len(s), rp*(t*2-1))
+		}
+		if len(m) != t*t || len(p) != t*t {
+			return nil, fmt.Errorf("poseidon: POSEIDON_M/P(%d) are not %dx%d matrices", t, t, t)
+		}
+		out[t] = &roundConstants{
+			t:        t,
+			nRoundsF: nRoundsF,
+			nRoundsP: rp,
+			c:        c,
+			s:        s,
+			m:        toMatrix(m, t),
+			p:        toMatrix(p, t),
+		}
+	}
+	return out, nil
+}
+
+// parseRounds reads N_ROUNDS_F() & N_ROUNDS_P(t)
+// N_ROUNDS_P is indexed by t-2
+func parseRounds(src string) (int, map[int]int, error) {
+	fMatch := reRoundsF.FindStringSubmatch(src)
+	pMatch := reRoundsP.FindStringSubmatch(src)
+	if fMatch == nil || pMatch == nil {
+		return 0, nil, fmt.Errorf("poseidon: unable to locate N_ROUNDS_F / N_ROUNDS_P")
+	}
+	nRoundsF, err := strconv.Atoi(fMatch[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	nRoundsP := make(map[int]int)
+	for i, v := range strings.Split(pMatch[1], ",") {
+		rp, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, nil, fmt.Errorf("poseidon: invalid N_ROUNDS_P entry %q", v)
+		}
+		nRoundsP[i+2] = rp
+	}
+	return nRoundsF, nRoundsP, nil
+}
+
+// parseFunctionTable extracts the (flattened) constants returned
+// by a Circom function of the form:
+//
+//	function NAME(t) { if (t==2) { return [...]; } else if (t==3) {...} }
+func parseFunctionTable(src, name string) (map[int][]*big.Int, error) {
+	start := strings.Index(src, "function "+name+"(")
+	if start < 0 {
+		return nil, fmt.Errorf("poseidon: function %s not found", name)
+	}
+	body := src[start+len("function "+name):]
+	if end := strings.Index(body, "\nfunction "); end >= 0 {
+		body = body[:end]
+	}
+
+	var (
+		table   = make(map[int][]*big.Int)
+		widths  = reWidth.FindAllStringSubmatchIndex(body, -1)
+		segment string
+	)
+	for i, w := range widths {
+		t, err := strconv.Atoi(body[w[2]:w[3]])
+		if err != nil {
+			return nil, err
+		}
+		if i+1 < len(widths) {
+			segment = body[w[1]:widths[i+1][0]]
+		} else {
+			segment = body[w[1]:]
+		}
+		hexes := reHex.FindAllString(segment, -1)
+		values := make([]*big.Int, len(hexes))
+		for j, h := range hexes {
+			v, ok := new(big.Int).SetString(h[2:], 16)
+			if !ok {
+				return nil, fmt.Errorf("poseidon: invalid constant %s in %s(%d)", h, name, t)
+			}
+			values[j] = v
+		}
+		table[t] = values
+	}
+	return table, nil
+}
+
+func toMatrix(flat []*big.Int, t int) [][]*big.Int {
+	m := make([][]*big.Int, t)
+	for i := 0; i < t; i++ {
+		m[i] = flat[i*t : (i+1)*t]
+	}
+	return m
+}
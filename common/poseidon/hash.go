@@ -0,0 +1,160 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+)
+
+// Native (Go) implementation of the Poseidon circuit blocks.
+// Each function mirrors a template of this package
+// and reads its constants from constants.circom,
+// so witness values can be computed without the circuit.
+
+// Permute applies STATE_PERMUTATION(t) to state
+// where t = len(state), state[0] being the domain / capacity element.
+func Permute(state []*big.Int) ([]*big.Int, error) {
+	constants, err := loadConstants()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		t      = len(state)
+		rc, ok = constants[t]
+	)
+	if !ok {
+		return nil, fmt.Errorf("poseidon: unsupported state width t=%d", t)
+	}
+
+	x := make([]*big.Int, t)
+	for i, s := range state {
+		if s == nil {
+			return nil, fmt.Errorf("poseidon: state[%d] is nil", i)
+		}
+		x[i] = field.Reduce(s)
+	}
+
+	// Phase 0: PRE_ROUND_PHASE
+	x = arc(x, rc.c, 0)
+
+	// Phase 1: FULL_ROUND_PHASE (first half)
+	for r := 0; r < rc.nRoundsF/2-1; r++ {
+		x = mixM(arc(multiSbox(x), rc.c, (r+1)*t), rc.m)
+	}
+
+	// Phase 2: PARTIAL_ROUND_PHASE
+	x = mixM(arc(multiSbox(x), rc.c, rc.nRoundsF/2*t), rc.p)
+	for r := 0; r < rc.nRoundsP; r++ {
+		x[0] = sbox(x[0])
+		x[0].Add(x[0], rc.c[(rc.nRoundsF/2+1)*t+r])
+		x[0].Mod(x[0], field.Modulus)
+		x = mixS(x, rc.s, r)
+	}
+
+	// Phase 3: FULL_ROUND_PHASE (second half)
+	for r := 0; r < rc.nRoundsF/2-1; r++ {
+		x = mixM(arc(multiSbox(x), rc.c, (rc.nRoundsF/2+1)*t+rc.nRoundsP+r*t), rc.m)
+	}
+
+	// Phase 4: FINAL_ROUND_PHASE
+	return mixM(multiSbox(x), rc.m), nil
+}
+
+// HashWithDomain mirrors POSEIDON_HASH(nIn, nOut)
+// returning the first nOut elements of the permuted state
+func HashWithDomain(inputs []*big.Int, domain *big.Int, nOut int) ([]*big.Int, error) {
+	t := len(inputs) + 1
+	if nOut < 1 || nOut > t {
+		return nil, fmt.Errorf("poseidon: nOut must be within [1, %d], got %d", t, nOut)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("poseidon: domain is nil")
+	}
+	state := make([]*big.Int, t)
+	state[0] = domain
+	copy(state[1:], inputs)
+
+	out, err := Permute(state)
+	if err != nil {
+		return nil, err
+	}
+	return out[:nOut], nil
+}
+
+// Hash mirrors POSEIDON_STD(n)
+// i.e. POSEIDON_HASH(n, 1) with a zero domain
+func Hash(inputs []*big.Int) (*big.Int, error) {
+	out, err := HashWithDomain(inputs, big.NewInt(0), 1)
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+// SBOX: x^5
+func sbox(x *big.Int) *big.Int {
+	z := new(big.Int).Mul(x, x)
+	z.Mod(z, field.Modulus)
+	z.Mul(z, z)
+	z.Mod(z, field.Modulus)
+	z.Mul(z, x)
+	return z.Mod(z, field.Modulus)
+}
+
+// MULTI_SBOX
+func multiSbox(x []*big.Int) []*big.Int {
+	z := make([]*big.Int, len(x))
+	for i := range x {
+		z[i] = sbox(x[i])
+	}
+	return z
+}
+
+// ARC(C, r, t)
+func arc(x []*big.Int, c []*big.Int, r int) []*big.Int {
+	z := make([]*big.Int, len(x))
+	for i := range x {
+		z[i] = new(big.Int).Add(x[i], c[i+r])
+		z[i].Mod(z[i], field.Modulus)
+	}
+	return z
+}
+
+// MIXM(m, t): z[i] = sum(m[j][i] * x[j])
+func mixM(x []*big.Int, m [][]*big.Int) []*big.Int {
+	var (
+		t   = len(x)
+		z   = make([]*big.Int, t)
+		mul = new(big.Int)
+	)
+	for i := 0; i < t; i++ {
+		z[i] = new(big.Int)
+		for j := 0; j < t; j++ {
+			z[i].Add(z[i], mul.Mul(m[j][i], x[j]))
+		}
+		z[i].Mod(z[i], field.Modulus)
+	}
+	return z
+}
+
+// MIXS(s, t, r)
+func mixS(x []*big.Int, s []*big.Int, r int) []*big.Int {
+	var (
+		t   = len(x)
+		z   = make([]*big.Int, t)
+		off = (t*2 - 1) * r
+		mul = new(big.Int)
+	)
+	z[0] = new(big.Int)
+	for i := 0; i < t; i++ {
+		z[0].Add(z[0], mul.Mul(s[off+i], x[i]))
+	}
+	z[0].Mod(z[0], field.Modulus)
+	for i := 1; i < t; i++ {
+		z[i] = new(big.Int).Mul(s[off+t+i-1], x[0])
+		z[i].Add(z[i], x[i])
+		z[i].Mod(z[i], field.Modulus)
+	}
+	return z
+}
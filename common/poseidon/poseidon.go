@@ -3,6 +3,8 @@ package poseidon
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -36,6 +38,10 @@ var PoseidonCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("poseidon.PoseidonCircuitPkg", PoseidonCircuitPkg)
+}
+
 var (
 	POSEIDON_STATIC_CONSTANTS = Program{
 		Identity: "POSEIDON_STATIC_CONSTANTS",
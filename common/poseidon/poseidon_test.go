@@ -1,8 +1,14 @@
 package poseidon
 
 import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
 	"testing"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
 	. "github.com/0xBow-io/veritas"
 	"github.com/test-go/testify/require"
 )
@@ -102,3 +108,90 @@ func Test_Poseidon_STD(t *testing.T) {
 	require.True(t, len(evaluation.SatisfiedConstraints()) > 0)
 	require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
 }
+
+// Vectors match GetExpected in Test_Poseidon_STD
+func Test_Hash(t *testing.T) {
+	tests := []struct {
+		inputs   []int64
+		expected string
+	}{
+		{[]int64{1}, "18586133768512220936620570745912940619677854269274689475585506675881198879027"},
+		{[]int64{1, 2}, "7853200120776062878684798364095072458815029376092732009249414926327459813530"},
+		{[]int64{1, 2, 0, 0, 0}, "1018317224307729531995786483840663576608797660851238720571059489595066344487"},
+		{[]int64{1, 2, 0, 0, 0, 0}, "15336558801450556532856248569924170992202208561737609669134139141992924267169"},
+		{[]int64{3, 4, 0, 0, 0}, "5811595552068139067952687508729883632420015185677766880877743348592482390548"},
+	}
+	for _, tc := range tests {
+		inputs := make([]*big.Int, len(tc.inputs))
+		for i, v := range tc.inputs {
+			inputs[i] = big.NewInt(v)
+		}
+		hash, err := Hash(inputs)
+		require.Nil(t, err)
+		require.Equal(t, tc.expected, hash.String(), "inputs: %v", tc.inputs)
+	}
+
+	_, err := Hash(make([]*big.Int, 17))
+	require.NotNil(t, err)
+	_, err = HashWithDomain([]*big.Int{big.NewInt(1)}, big.NewInt(0), 3)
+	require.NotNil(t, err)
+}
+
+// Evaluate POSEIDON_HASH(nIn, nOut) on random inputs & domain
+// and compare against the native implementation
+func Test_Hash_Circuit(t *testing.T) {
+	for _, params := range [][2]int{{1, 1}, {2, 2}, {3, 4}, {5, 1}, {8, 3}, {16, 17}} {
+		var (
+			nIn, nOut = params[0], params[1]
+			lib       = NewEmptyLibrary()
+		)
+		reports, err := lib.Compile(CircuitPkg{
+			TargetVersion: "2.2.0",
+			Field:         "bn128",
+			Programs: []Program{
+				{
+					Identity: "main",
+					Src:      fmt.Sprintf("component main {public[inputs, domain]} = POSEIDON_HASH(%d, %d);", nIn, nOut),
+				},
+			},
+		}, PoseidonCircuitPkg)
+		require.Nil(t, err)
+		require.Len(t, reports, 0, reports.String())
+
+		for round := 0; round < 3; round++ {
+			var (
+				inputs = make([]*big.Int, nIn)
+				values = make([]string, nIn)
+				domain = randomElement(t)
+			)
+			for i := range inputs {
+				inputs[i] = randomElement(t)
+				values[i] = `"` + inputs[i].String() + `"`
+			}
+			expected, err := HashWithDomain(inputs, domain, nOut)
+			require.Nil(t, err)
+
+			evaluation, err := lib.Evaluate([]byte(fmt.Sprintf(
+				`{"inputs":[%s],"domain":"%s"}`, strings.Join(values, ","), domain.String(),
+			)))
+			require.Nil(t, err)
+			require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+			for i := 0; i < nOut; i++ {
+				require.Equal(t, expected[i], harness.Signal(t, evaluation, fmt.Sprintf("main.hash[%d]", i)),
+					"POSEIDON_HASH(%d, %d) hash[%d]", nIn, nOut, i)
+			}
+		}
+		lib.Burn()
+	}
+}
+
+func randomElement(t *testing.T) *big.Int {
+	v, err := rand.Int(rand.Reader, field.Modulus)
+	require.Nil(t, err)
+	return v
+}
+
+func Test_Poseidon_STD_Vectors(t *testing.T) {
+	harness.RunFile(t, "testdata/poseidon_std.yaml", PoseidonCircuitPkg)
+}
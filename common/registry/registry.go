@@ -0,0 +1,333 @@
+// Package registry tracks the circuit pkgs of this module
+// and the pkgs each of them requires but doesn't include.
+// Every circuit pkg registers itself with Default on init,
+// Resolve then returns a pkg along with all of its dependencies.
+// Importing the root privacypool package registers all of them:
+//
+//	pkgs, err := privacypool.Resolve("ecdh.EcdhCircuitPkg")
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	. "github.com/0xBow-io/veritas"
+)
+
+var (
+	ErrUnknownPkg           = errors.New("unknown circuit pkg")
+	ErrMissingDependency    = errors.New("missing circuit pkg dependency")
+	ErrCircularDependency   = errors.New("circular template dependency")
+	ErrConflictingTemplates = errors.New("template defined by multiple circuit pkgs")
+)
+
+var (
+	// matches template & function definitions
+	reDefinition = regexp.MustCompile(`\b(?:template|function)\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	// matches anything that is called / instantiated
+	reReference = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	// matches line & block comments
+	reComment = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+)
+
+// entry is a registered circuit pkg along
+// with the names of the pkgs it requires
+type entry struct {
+	name     string
+	pkg      CircuitPkg
+	requires []string
+}
+
+// Registry holds circuit pkgs by name
+// and resolves their dependencies
+//
+// Circuit pkgs may require each other mutually
+// (i.e. Bitify & Comparators) as circom only needs
+// every template to be present at compile time.
+// Circularity is hence checked between templates.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]entry)}
+}
+
+// Register declares pkg under name with the
+// names of the circuit pkgs it requires.
+// Registering the same name twice panics.
+func (r *Registry) Register(name string, pkg CircuitPkg, requires ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[name]; ok {
+		panic("registry: circuit pkg registered twice: " + name)
+	}
+	r.entries[name] = entry{name: name, pkg: pkg, requires: requires}
+}
+
+// Names returns the sorted names of the registered pkgs
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NameOf returns the name of the first registered pkg
+// (by name) containing a program with the given identity
+func (r *Registry) NameOf(identity string) (string, bool) {
+	for _, name := range r.Names() {
+		r.mu.RLock()
+		programs := r.entries[name].pkg.Programs
+		r.mu.RUnlock()
+		for _, p := range programs {
+			if p.Identity == identity {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Resolve returns the closed, de-duplicated set of circuit pkgs
+// required by the named pkgs, dependencies first.
+// Errors if a required pkg is unknown, a template references
+// a template of a pkg that was not required,
+// or templates depend on each other circularly.
+func (r *Registry) Resolve(names ...string) ([]CircuitPkg, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		order   []entry
+		visited = make(map[string]bool)
+		visit   func(name, by string) error
+	)
+	visit = func(name, by string) error {
+		if visited[name] {
+			return nil
+		}
+		e, ok := r.entries[name]
+		if !ok {
+			if by == "" {
+				return fmt.Errorf("%w: %s", ErrUnknownPkg, name)
+			}
+			return fmt.Errorf("%w: %s required by %s (is its go package imported?)", ErrUnknownPkg, name, by)
+		}
+		visited[name] = true
+		for _, dep := range e.requires {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+		}
+		order = append(order, e)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.check(order); err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]CircuitPkg, len(order))
+	for i, e := range order {
+		pkgs[i] = e.pkg
+	}
+	return pkgs, nil
+}
+
+// check scans the templates of the resolved pkgs
+// for references to templates outside of the resolved set
+// and for circular references between templates
+func (r *Registry) check(resolved []entry) error {
+	var (
+		// template name -> pkg name for every registered pkg
+		known = make(map[string]string)
+		// template name -> pkg name for the resolved pkgs
+		defined = make(map[string]string)
+		sources = make(map[string]string)
+		errs    []error
+	)
+	// the first registered pkg by name
+	// defining a template is reported as its owner
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, p := range r.entries[name].pkg.Programs {
+			for def := range Definitions(p.Src) {
+				if _, ok := known[def]; !ok {
+					known[def] = name
+				}
+			}
+		}
+	}
+	for _, e := range resolved {
+		for _, p := range e.pkg.Programs {
+			for def, src := range Definitions(p.Src) {
+				if other, ok := defined[def]; ok && other != e.name {
+					errs = append(errs, fmt.Errorf("%w: %s in %s and %s", ErrConflictingTemplates, def, other, e.name))
+					continue
+				}
+				defined[def] = e.name
+				sources[def] = src
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// template name -> referenced template names
+	var (
+		graph   = make(map[string][]string)
+		missing = make(map[[2]string]bool)
+		defs    = make([]string, 0, len(sources))
+	)
+	for def := range sources {
+		defs = append(defs, def)
+	}
+	sort.Strings(defs)
+	for _, def := range defs {
+		for _, ref := range References(def, sources[def]) {
+			if _, ok := defined[ref]; ok {
+				graph[def] = append(graph[def], ref)
+				continue
+			}
+			pkg, ok := known[ref]
+			if !ok || missing[[2]string{defined[def], pkg}] {
+				continue
+			}
+			// report once per pkg pair
+			missing[[2]string{defined[def], pkg}] = true
+			errs = append(errs, fmt.Errorf("%w: %s requires %s (%s references %s)",
+				ErrMissingDependency, defined[def], pkg, def, ref))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return checkCycles(graph)
+}
+
+// checkCycles returns the first cycle found in graph
+func checkCycles(graph map[string][]string) error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	var (
+		state = make(map[string]int)
+		path  []string
+		visit func(node string) error
+	)
+	visit = func(node string) error {
+		switch state[node] {
+		case visiting:
+			for i, n := range path {
+				if n == node {
+					return fmt.Errorf("%w: %s", ErrCircularDependency,
+						strings.Join(append(path[i:], node), " -> "))
+				}
+			}
+		case done:
+			return nil
+		}
+		state[node] = visiting
+		path = append(path, node)
+		for _, next := range graph[node] {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = done
+		return nil
+	}
+
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if err := visit(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Definitions splits src into the templates
+// & functions it defines, comments excluded
+func Definitions(src string) map[string]string {
+	defs := make(map[string]string)
+	src = reComment.ReplaceAllString(src, "")
+	matches := reDefinition.FindAllStringSubmatchIndex(src, -1)
+	for i, m := range matches {
+		end := len(src)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		defs[src[m[2]:m[3]]] = src[m[0]:end]
+	}
+	return defs
+}
+
+// References returns the unique names that are called
+// or instantiated in the src of def, excluding def itself
+// so that recursive functions are allowed
+func References(def, src string) []string {
+	var (
+		seen = map[string]bool{def: true}
+		refs []string
+	)
+	for _, m := range reReference.FindAllStringSubmatch(src, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			refs = append(refs, m[1])
+		}
+	}
+	return refs
+}
+
+// Default is the registry every circuit pkg
+// of this module registers itself with
+var Default = NewRegistry()
+
+// Register declares pkg in the Default registry
+func Register(name string, pkg CircuitPkg, requires ...string) {
+	Default.Register(name, pkg, requires...)
+}
+
+// Resolve resolves the named pkgs from the Default registry
+func Resolve(names ...string) ([]CircuitPkg, error) {
+	return Default.Resolve(names...)
+}
+
+// Names returns the names of the pkgs in the Default registry
+func Names() []string {
+	return Default.Names()
+}
+
+// NameOf looks up identity in the Default registry
+func NameOf(identity string) (string, bool) {
+	return Default.NameOf(identity)
+}
@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+func testPkg(programs ...Program) CircuitPkg {
+	return CircuitPkg{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs:      programs,
+	}
+}
+
+var (
+	progA = Program{Identity: "A", Src: `
+		template A() {
+            // B() is referenced in a comment only
+            signal input in;
+            signal output out <== in;
+        }`}
+	progB = Program{Identity: "B", Src: `
+		function fib(n) { if (n < 2) { return n; } return fib(n-1) + fib(n-2); }
+		template B() {
+            signal input in;
+            signal output out <== A()(in) * fib(3);
+        }`}
+	progC = Program{Identity: "C", Src: `
+		template C() {
+            signal input in;
+            signal output out <== B()(in);
+            log(out);
+        }`}
+	progCycle = Program{Identity: "D", Src: `
+		template D() {
+            signal input in;
+            signal output out <== E()(in);
+        }
+		template E() {
+            signal input in;
+            signal output out <== D()(in);
+        }`}
+)
+
+func Test_Resolve(t *testing.T) {
+	r := NewRegistry()
+	// pkgs can require each other mutually
+	r.Register("a", testPkg(progA), "b")
+	r.Register("b", testPkg(progB), "a")
+	r.Register("c", testPkg(progC), "b", "a")
+
+	pkgs, err := r.Resolve("c", "a")
+	require.Nil(t, err)
+	require.Equal(t, []CircuitPkg{testPkg(progA), testPkg(progB), testPkg(progC)}, pkgs)
+
+	pkgs, err = r.Resolve("a")
+	require.Nil(t, err)
+	require.Len(t, pkgs, 2)
+
+	require.Panics(t, func() { r.Register("a", testPkg(progA)) })
+}
+
+func Test_Resolve_Errors(t *testing.T) {
+	r := NewRegistry()
+	r.Register("a", testPkg(progA))
+	r.Register("b", testPkg(progB))
+	r.Register("c", testPkg(progC), "b")
+	r.Register("d", testPkg(progCycle))
+	r.Register("e", testPkg(progA, progB), "x")
+	r.Register("f", testPkg(progA, progB))
+
+	_, err := r.Resolve("x")
+	require.True(t, errors.Is(err, ErrUnknownPkg))
+
+	_, err = r.Resolve("e")
+	require.True(t, errors.Is(err, ErrUnknownPkg))
+	require.Contains(t, err.Error(), "x required by e")
+
+	_, err = r.Resolve("c")
+	require.True(t, errors.Is(err, ErrMissingDependency))
+	require.Contains(t, err.Error(), "b requires a (B references A)")
+
+	_, err = r.Resolve("d")
+	require.True(t, errors.Is(err, ErrCircularDependency))
+	require.Contains(t, err.Error(), "D -> E -> D")
+
+	_, err = r.Resolve("f", "b")
+	require.True(t, errors.Is(err, ErrConflictingTemplates))
+}
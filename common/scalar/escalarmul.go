@@ -3,6 +3,8 @@ package scalar
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -10,7 +12,7 @@ import (
 // to support scalar multiplication operations
 //
 // These pkgs are required but not included here:
-// Comparators, babyjub, Montgomery.
+// Comparators, babyjub, Montgomery, Multiplexer.
 var EscalarMulCircuitPkg = CircuitPkg{
 	TargetVersion: "2.2.0",
 	Field:         "bn128",
@@ -25,6 +27,15 @@ var EscalarMulCircuitPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("scalar.EscalarMulCircuitPkg", EscalarMulCircuitPkg,
+		"babyjub.BabyJubCircuitPkg",
+		"babyjub.MontGomeryCircuitPkg",
+		"comparators.ComparatorsCircuitPkg",
+		"multiplexer.MultiplexerCircuitPkg",
+	)
+}
+
 // Below Implementations are from circomlib
 // See: https://github.com/iden3/circomlib/
 var (
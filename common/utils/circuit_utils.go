@@ -3,6 +3,8 @@ package utils
 import (
 	_ "embed"
 
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
 	. "github.com/0xBow-io/veritas"
 )
 
@@ -19,6 +21,12 @@ var CircuitUtilsPkg = CircuitPkg{
 	},
 }
 
+func init() {
+	registry.Register("utils.CircuitUtilsPkg", CircuitUtilsPkg,
+		"bit.BitifyCircuitPkg",
+	)
+}
+
 var (
 	AliasCheck = Program{
 		Identity: "AliasCheck",
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+)
+
+// AssetTupleLen is the minimum length of asset tagged commitment tuples:
+// [value, scope, secret.x, secret.y, assetId]
+// (see PrivacyPoolWithAssets)
+const AssetTupleLen = TupleLen + 1
+
+// NewAssetCommitment builds the commitment of value
+// denominated in assetId (see NewCommitment)
+func NewAssetCommitment(scope, assetId, value, privateKey, saltPrivateKey, nonce *big.Int, data ...*big.Int) (*Commitment, error) {
+	if assetId == nil {
+		return nil, fmt.Errorf("%w: assetId is required", ErrInvalidCommitment)
+	}
+	return NewCommitment(scope, value, privateKey, saltPrivateKey, nonce, append([]*big.Int{assetId}, data...)...)
+}
+
+// OpenAssetCommitment is OpenCommitment for asset tagged commitments
+func OpenAssetCommitment(privateKey *big.Int, saltPublicKey *babyjub.Point, nonce *big.Int, ciphertext []*big.Int, tupleLen int) (*Commitment, error) {
+	if tupleLen < AssetTupleLen {
+		return nil, fmt.Errorf("%w: tuple length %d < %d", ErrInvalidCommitment, tupleLen, AssetTupleLen)
+	}
+	return OpenCommitment(privateKey, saltPublicKey, nonce, ciphertext, tupleLen)
+}
+
+// AssetID returns the asset identifier of an
+// asset tagged commitment, nil otherwise
+func (c *Commitment) AssetID() *big.Int {
+	if len(c.Tuple) < AssetTupleLen {
+		return nil
+	}
+	return c.Tuple[TupleLen]
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/test-go/testify/require"
+)
+
+func Test_AssetCommitment(t *testing.T) {
+	var (
+		scope   = randomElement(t)
+		assetId = randomElement(t)
+	)
+	commitment, err := NewAssetCommitment(scope, assetId, big.NewInt(1000), randomPrivateKey(t), randomPrivateKey(t), randomNonce(t))
+	require.Nil(t, err)
+	require.Len(t, commitment.Tuple, AssetTupleLen)
+	require.Equal(t, assetId, commitment.AssetID())
+
+	opened, err := OpenAssetCommitment(commitment.PrivateKey, commitment.SaltPublicKey, commitment.Nonce, commitment.Ciphertext, AssetTupleLen)
+	require.Nil(t, err)
+	require.Equal(t, commitment, opened)
+
+	// untagged commitments
+	_, err = OpenAssetCommitment(commitment.PrivateKey, commitment.SaltPublicKey, commitment.Nonce, commitment.Ciphertext, TupleLen)
+	require.NotNil(t, err)
+	require.Nil(t, randomCommitment(t, scope, big.NewInt(1)).AssetID())
+
+	// only the owner can open the commitment
+	_, err = OpenAssetCommitment(randomPrivateKey(t), commitment.SaltPublicKey, commitment.Nonce, commitment.Ciphertext, AssetTupleLen)
+	require.NotNil(t, err)
+
+	_, err = NewAssetCommitment(scope, nil, big.NewInt(1000), randomPrivateKey(t), randomPrivateKey(t), randomNonce(t))
+	require.NotNil(t, err)
+}
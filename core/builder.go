@@ -0,0 +1,219 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+)
+
+// TupleLen is the minimum length of the commitment tuple:
+// [value, scope, secret.x, secret.y], any additional
+// elements are appended to the tuple as is
+const TupleLen = 4
+
+// ValueBits is the bit width commitment values
+// are range checked to by the PrivacyPool templates
+const ValueBits = 128
+
+var ErrInvalidCommitment = errors.New("invalid commitment")
+
+// CheckValue errors unless value fits in ValueBits bits,
+// values outside of it fail the PrivacyPool templates
+func CheckValue(value *big.Int) error {
+	if value == nil || value.Sign() < 0 || value.BitLen() > ValueBits {
+		return fmt.Errorf("%w: value is not a %d bits value", ErrInvalidCommitment, ValueBits)
+	}
+	return nil
+}
+
+// CommitmentKeys mirrors the outputs of RecoverCommitmentKeys
+type CommitmentKeys struct {
+	PublicKey     *babyjub.Point `json:"publicKey"`
+	SecretKey     *babyjub.Point `json:"secretKey"`
+	EncryptionKey *babyjub.Point `json:"encryptionKey"`
+}
+
+// Commitment holds every value derived by CommitmentOwnershipProof
+// for a single commitment, alongside the private inputs used to derive them
+type Commitment struct {
+	Scope      *big.Int `json:"scope"`
+	Value      *big.Int `json:"value"`
+	PrivateKey *big.Int `json:"privateKey"`
+	Nonce      *big.Int `json:"nonce"`
+
+	SaltPublicKey *babyjub.Point `json:"saltPublicKey"`
+	CommitmentKeys
+
+	Tuple          []*big.Int `json:"tuple"`
+	Ciphertext     []*big.Int `json:"ciphertext"`
+	NullRoot       *big.Int   `json:"nullRoot"`
+	CommitmentHash *big.Int   `json:"commitmentHash"`
+	CommitmentRoot *big.Int   `json:"commitmentRoot"`
+}
+
+// RecoverKeys is the native (Go) counterpart of RecoverCommitmentKeys
+func RecoverKeys(privateKey *big.Int, saltPublicKey *babyjub.Point) (*CommitmentKeys, error) {
+	publicKey, err := babyjub.PrivToPub(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := ecdh.SharedKey(privateKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	// mirrors BabySubGroupCheck in RecoverCommitmentKeys
+	if !saltPublicKey.InSubGroup() {
+		return nil, babyjub.ErrPointNotInSubGroup
+	}
+	encryptionKey, err := ecdh.SharedKey(privateKey, saltPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitmentKeys{
+		PublicKey:     publicKey,
+		SecretKey:     secretKey,
+		EncryptionKey: encryptionKey,
+	}, nil
+}
+
+// NewCommitment builds the commitment of value under scope
+// owned by privateKey and encrypted with the ECDH shared key
+// of privateKey & the public key of saltPrivateKey.
+// data is appended to the tuple, the tuple length
+// is then TupleLen + len(data)
+func NewCommitment(scope, value, privateKey, saltPrivateKey, nonce *big.Int, data ...*big.Int) (*Commitment, error) {
+	if scope == nil || value == nil {
+		return nil, fmt.Errorf("%w: scope & value are required", ErrInvalidCommitment)
+	}
+	for i, d := range data {
+		if d == nil {
+			return nil, fmt.Errorf("%w: data[%d] is nil", ErrInvalidCommitment, i)
+		}
+	}
+	saltPublicKey, err := babyjub.PrivToPub(saltPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: salt %v", ErrInvalidCommitment, err)
+	}
+	keys, err := RecoverKeys(privateKey, saltPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCommitment, err)
+	}
+
+	//  [value, scope, secret.x, secret.y, data...]
+	tuple := []*big.Int{
+		new(big.Int).Set(value),
+		new(big.Int).Set(scope),
+		keys.SecretKey.X,
+		keys.SecretKey.Y,
+	}
+	for _, d := range data {
+		tuple = append(tuple, new(big.Int).Set(d))
+	}
+	ciphertext, err := PoseidonEncrypt(tuple, [2]*big.Int{keys.EncryptionKey.X, keys.EncryptionKey.Y}, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCommitment, err)
+	}
+
+	hash, err := poseidon.Hash(tuple)
+	if err != nil {
+		return nil, err
+	}
+	nullRoot, err := ComputeNullRoot(keys, saltPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	commitmentRoot, err := ComputeCommitmentRoot(ciphertext, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Commitment{
+		Scope:          new(big.Int).Set(scope),
+		Value:          new(big.Int).Set(value),
+		PrivateKey:     new(big.Int).Set(privateKey),
+		Nonce:          new(big.Int).Set(nonce),
+		SaltPublicKey:  saltPublicKey,
+		CommitmentKeys: *keys,
+		Tuple:          tuple,
+		Ciphertext:     ciphertext,
+		NullRoot:       nullRoot,
+		CommitmentHash: hash,
+		CommitmentRoot: commitmentRoot,
+	}, nil
+}
+
+// OpenCommitment decrypts & authenticates the ciphertext of a commitment
+// with a tuple of tupleLen elements owned by privateKey, rebuilding
+// the Commitment as NewCommitment did.
+// The tuple secret must match the one derived from privateKey
+func OpenCommitment(privateKey *big.Int, saltPublicKey *babyjub.Point, nonce *big.Int, ciphertext []*big.Int, tupleLen int) (*Commitment, error) {
+	if tupleLen < TupleLen {
+		return nil, fmt.Errorf("%w: tuple length %d < %d", ErrInvalidCommitment, tupleLen, TupleLen)
+	}
+	keys, err := RecoverKeys(privateKey, saltPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCommitment, err)
+	}
+	tuple, err := PoseidonDecrypt(ciphertext, [2]*big.Int{keys.EncryptionKey.X, keys.EncryptionKey.Y}, nonce, tupleLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCommitment, err)
+	}
+	//  [value, scope, secret.x, secret.y, data...]
+	if tuple[2].Cmp(keys.SecretKey.X) != 0 || tuple[3].Cmp(keys.SecretKey.Y) != 0 {
+		return nil, fmt.Errorf("%w: secret mismatch", ErrInvalidCommitment)
+	}
+
+	hash, err := poseidon.Hash(tuple)
+	if err != nil {
+		return nil, err
+	}
+	nullRoot, err := ComputeNullRoot(keys, saltPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	commitmentRoot, err := ComputeCommitmentRoot(ciphertext, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Commitment{
+		Scope:          tuple[1],
+		Value:          tuple[0],
+		PrivateKey:     new(big.Int).Set(privateKey),
+		Nonce:          new(big.Int).Set(nonce),
+		SaltPublicKey:  saltPublicKey,
+		CommitmentKeys: *keys,
+		Tuple:          tuple,
+		Ciphertext:     append([]*big.Int{}, ciphertext...),
+		NullRoot:       nullRoot,
+		CommitmentHash: hash,
+		CommitmentRoot: commitmentRoot,
+	}, nil
+}
+
+// ComputeNullRoot computes the root of the 8-leaf tree of all keys
+// involved with a commitment (see CommitmentOwnershipProof)
+func ComputeNullRoot(keys *CommitmentKeys, saltPublicKey *babyjub.Point) (*big.Int, error) {
+	return merkletree.ComputeRoot([]*big.Int{
+		keys.PublicKey.X, keys.PublicKey.Y,
+		keys.SecretKey.X, keys.SecretKey.Y,
+		saltPublicKey.X, saltPublicKey.Y,
+		keys.EncryptionKey.X, keys.EncryptionKey.Y,
+	})
+}
+
+// ComputeCommitmentRoot computes the root of the tree
+// with the ciphertext & commitment hash as leaves,
+// zero padded to the next power of 2
+func ComputeCommitmentRoot(ciphertext []*big.Int, hash *big.Int) (*big.Int, error) {
+	leaves := append(append(make([]*big.Int, 0, len(ciphertext)+1), ciphertext...), hash)
+	for len(leaves) < 2 || len(leaves)&(len(leaves)-1) != 0 {
+		leaves = append(leaves, big.NewInt(0))
+	}
+	return merkletree.ComputeRoot(leaves)
+}
@@ -39,6 +39,47 @@ var (
         }
 	`}
 
+	// PoseidonDecryptTemplate is the PoseidonDecrypt template:
+	// PoseidonDecryptWithoutCheck with the authentication element
+	// (decryptedLast) checked against the last ciphertext element
+	// and the zero padding of the message checked to be zero
+	// (zk-kit's PoseidonDecrypt, see the native PoseidonDecrypt)
+	PoseidonDecryptTemplate = Program{
+		Identity: "PoseidonDecrypt",
+		Src: `
+		template PoseidonDecrypt(length) {
+            var decryptedLength = length;
+            while (decryptedLength % 3 != 0) {
+                decryptedLength++;
+            }
+
+            input signal ciphertext[decryptedLength+1];
+            input signal nonce;
+            input signal key[2];
+            output signal decrypted[length];
+
+            component iterations = PoseidonDecryptIterations(length);
+            iterations.nonce <== nonce;
+            iterations.key[0] <== key[0];
+            iterations.key[1] <== key[1];
+            for (var i = 0; i < decryptedLength + 1; i++) {
+                iterations.ciphertext[i] <== ciphertext[i];
+            }
+
+            // check the authentication element
+            iterations.decryptedLast === ciphertext[decryptedLength];
+
+            // check the zero padding
+            for (var i = length; i < decryptedLength; i++) {
+                iterations.decrypted[i] === 0;
+            }
+
+            for (var i = 0; i < length; i ++) {
+                decrypted[i] <== iterations.decrypted[i];
+            }
+        }
+	`}
+
 	PoseidonDecryptIterations = Program{
 		Identity: "PoseidonDecryptIterations",
 		Src: `
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/bit"
+	"github.com/0xBow-io/privacy-pool-veritas/common/comparators"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+	"github.com/0xBow-io/privacy-pool-veritas/common/utils"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+func Test_PoseidonEncrypt_RoundTrip(t *testing.T) {
+	for l := 1; l <= 10; l++ {
+		var (
+			tuple = randomElements(t, l)
+			key   = [2]*big.Int{randomElement(t), randomElement(t)}
+			nonce = randomNonce(t)
+		)
+		ciphertext, err := PoseidonEncrypt(tuple, key, nonce)
+		require.Nil(t, err)
+		require.Len(t, ciphertext, CiphertextLength(l))
+
+		decrypted, err := PoseidonDecrypt(ciphertext, key, nonce, l)
+		require.Nil(t, err)
+		require.Equal(t, tuple, decrypted)
+
+		// tampering with any element breaks authentication
+		tampered := append([]*big.Int{}, ciphertext...)
+		tampered[0] = field.Reduce(new(big.Int).Add(tampered[0], big.NewInt(1)))
+		_, err = PoseidonDecrypt(tampered, key, nonce, l)
+		require.Equal(t, ErrAuthenticationFailed, err)
+
+		// wrong nonce
+		_, err = PoseidonDecrypt(ciphertext, key, new(big.Int).Add(nonce, big.NewInt(1)), l)
+		require.Equal(t, ErrAuthenticationFailed, err)
+	}
+
+	// authenticated ciphertext with a non-zero padding
+	var (
+		key     = [2]*big.Int{randomElement(t), randomElement(t)}
+		nonce   = randomNonce(t)
+		message = append(randomElements(t, 4), big.NewInt(0), big.NewInt(1))
+	)
+	ciphertext, err := poseidonEncryptIterations(message, 4, key, nonce)
+	require.Nil(t, err)
+	_, err = PoseidonDecrypt(ciphertext, key, nonce, 4)
+	require.Equal(t, ErrInvalidPadding, err)
+
+	_, err = PoseidonEncrypt(randomElements(t, 4), [2]*big.Int{big.NewInt(1), big.NewInt(2)}, two128)
+	require.Equal(t, ErrInvalidNonce, err)
+
+	_, err = PoseidonDecrypt(randomElements(t, 6), [2]*big.Int{big.NewInt(1), big.NewInt(2)}, big.NewInt(0), 4)
+	require.Equal(t, ErrInvalidCiphertextLength, err)
+}
+
+// Decrypt Go-encrypted ciphertexts with PoseidonDecryptIterations
+func Test_PoseidonEncrypt_Circuit(t *testing.T) {
+	for _, l := range []int{1, 3, 4, 7} {
+		lib := NewEmptyLibrary()
+		reports, err := lib.Compile(
+			CircuitPkg{
+				TargetVersion: "2.2.0",
+				Field:         "bn128",
+				Programs: []Program{
+					{
+						Identity: "main",
+						Src:      fmt.Sprintf("component main {public[ciphertext, nonce]} = PoseidonDecryptIterations(%d);", l),
+					},
+					PoseidonDecryptIterations,
+				},
+			},
+			poseidon.PoseidonCircuitPkg,
+			comparators.ComparatorsCircuitPkg,
+			bit.BitifyCircuitPkg,
+			utils.CircuitUtilsPkg,
+		)
+		require.Nil(t, err)
+		require.False(t, harness.HasErrors(reports), reports.String())
+
+		var (
+			tuple = randomElements(t, l)
+			key   = [2]*big.Int{randomElement(t), randomElement(t)}
+			nonce = randomNonce(t)
+		)
+		ciphertext, err := PoseidonEncrypt(tuple, key, nonce)
+		require.Nil(t, err)
+
+		evaluation, err := lib.Evaluate([]byte(fmt.Sprintf(
+			`{"ciphertext":%s,"nonce":"%s","key":%s}`,
+			jsonArray(ciphertext), nonce.String(), jsonArray(key[:]),
+		)))
+		require.Nil(t, err)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+		for i := 0; i < paddedLength(l); i++ {
+			expected := big.NewInt(0)
+			if i < l {
+				expected = tuple[i]
+			}
+			require.Equal(t, expected, harness.Signal(t, evaluation, fmt.Sprintf("main.decrypted[%d]", i)))
+		}
+		require.Equal(t, ciphertext[len(ciphertext)-1], harness.Signal(t, evaluation, "main.decryptedLast"))
+		lib.Burn()
+	}
+}
+
+// Checked decryption (DecryptCommitment with checked = 1) rejects
+// unauthenticated ciphertexts & non-zero padding
+// whereas the unchecked mode decrypts them regardless
+func Test_DecryptCommitment_Checked(t *testing.T) {
+	var (
+		key   = [2]*big.Int{randomElement(t), randomElement(t)}
+		nonce = randomNonce(t)
+		tuple = randomElements(t, 4)
+	)
+	valid, err := PoseidonEncrypt(tuple, key, nonce)
+	require.Nil(t, err)
+
+	unauthenticated := append([]*big.Int{}, valid...)
+	unauthenticated[len(valid)-1] = field.Reduce(new(big.Int).Add(valid[len(valid)-1], big.NewInt(1)))
+
+	padded, err := poseidonEncryptIterations(append(append([]*big.Int{}, tuple...), big.NewInt(1), big.NewInt(0)), 4, key, nonce)
+	require.Nil(t, err)
+
+	for _, checked := range []int{0, 1} {
+		lib := NewEmptyLibrary()
+		reports, err := lib.Compile(append([]CircuitPkg{{
+			TargetVersion: "2.2.0",
+			Field:         "bn128",
+			Programs: []Program{{
+				Identity: "main",
+				Src:      fmt.Sprintf("component main {public[ciphertext, nonce]} = DecryptCommitment(7, 4, %d);", checked),
+			}},
+		}}, coreCircuitPkgs...)...)
+		require.Nil(t, err)
+		require.False(t, harness.HasErrors(reports), reports.String())
+
+		for _, tc := range []struct {
+			name       string
+			ciphertext []*big.Int
+			valid      bool
+		}{
+			{"valid", valid, true},
+			{"unauthenticated", unauthenticated, checked == 0},
+			{"padding", padded, checked == 0},
+		} {
+			evaluation, err := lib.Evaluate([]byte(fmt.Sprintf(
+				`{"ciphertext":%s,"nonce":"%s","encryptionKey":%s}`,
+				jsonArray(tc.ciphertext), nonce.String(), jsonArray(key[:]),
+			)))
+			require.Nil(t, err)
+			if !tc.valid {
+				require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), "checked=%d %s", checked, tc.name)
+				continue
+			}
+			require.Len(t, evaluation.UnSatisfiedConstraints(), 0, "checked=%d %s", checked, tc.name)
+			for i := range tuple {
+				require.Equal(t, tuple[i], harness.Signal(t, evaluation, fmt.Sprintf("main.tuple[%d]", i)))
+			}
+		}
+		lib.Burn()
+	}
+}
+
+func randomElement(t *testing.T) *big.Int {
+	v, err := rand.Int(rand.Reader, field.Modulus)
+	require.Nil(t, err)
+	return v
+}
+
+func randomElements(t *testing.T, n int) []*big.Int {
+	out := make([]*big.Int, n)
+	for i := range out {
+		out[i] = randomElement(t)
+	}
+	return out
+}
+
+func randomNonce(t *testing.T) *big.Int {
+	v, err := rand.Int(rand.Reader, two128)
+	require.Nil(t, err)
+	return v
+}
+
+func jsonArray(values []*big.Int) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = `"` + v.String() + `"`
+	}
+	return "[" + strings.Join(quoted, ",") + "]"
+}
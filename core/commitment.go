@@ -19,6 +19,10 @@ var (
             output signal secretKey[2];
             output signal encryptionKey[2];
 
+            // a saltPublicKey shifted by a low order point
+            // can yield the same encryptionKey under another nullRoot
+            BabySubGroupCheck()(saltPublicKey);
+
             var computedPublicKey[2] = BabyPrivToPubKey()(privateKey);
 
             publicKey <== computedPublicKey;
@@ -30,7 +34,17 @@ var (
 	DecryptCommitment = Program{
 		Identity: "DecryptCommitment",
 		Src: `
-		template DecryptCommitment(cipherLen, tupleLen){
+		template DecryptCommitment(cipherLen, tupleLen, checked){
+            // the tuple is zero padded to a multiple of 3
+            // followed by the authentication element
+            var decryptedLength = tupleLen;
+            while (decryptedLength % 3 != 0) {
+                decryptedLength++;
+            }
+            assert(tupleLen > 0);
+            assert(cipherLen == decryptedLength + 1);
+            assert(checked == 0 || checked == 1);
+
             input signal encryptionKey[2];               // ecdh shared secret key
             input signal nonce;                          // nonce value for Poseidon decryption
             input signal ciphertext[cipherLen];          // encrypted commitment tuple
@@ -38,14 +52,26 @@ var (
             output signal tuple[tupleLen];
             output signal hash;
 
-            var decryptor[cipherLen-1] = PoseidonDecryptWithoutCheck(tupleLen)(
-                [
-                    ciphertext[0], ciphertext[1], ciphertext[2], ciphertext[3],
-                    ciphertext[4], ciphertext[5], ciphertext[6]
-                ],
-                nonce,
-                encryptionKey
-            );
+            // checked = 1 enforces the authentication element
+            // & zero padding of the ciphertext (PoseidonDecrypt),
+            // an invalid ciphertext then fails the circuit
+            var decryptor[decryptedLength];
+            if (checked == 1) {
+                var decrypted[tupleLen] = PoseidonDecrypt(tupleLen)(
+                    ciphertext,
+                    nonce,
+                    encryptionKey
+                );
+                for (var i = 0; i < tupleLen; i++) {
+                    decryptor[i] = decrypted[i];
+                }
+            } else {
+                decryptor = PoseidonDecryptWithoutCheck(tupleLen)(
+                    ciphertext,
+                    nonce,
+                    encryptionKey
+                );
+            }
 
             var recovered[tupleLen];
             for (var i = 0; i < tupleLen; i++) {
@@ -60,7 +86,10 @@ var (
 	CommitmentOwnershipProof = Program{
 		Identity: "CommitmentOwnershipProof",
 		Src: `
-		template CommitmentOwnershipProof(cipherLen, tupleLen){
+		template CommitmentOwnershipProof(cipherLen, tupleLen, checked){
+            // [value, scope, secret.x, secret.y] prefix the tuple
+            assert(tupleLen >= 4);
+
             input signal scope;
             input signal privateKey;                // EdDSA private key
             input signal saltPublicKey[2];          // used to derive the encryptionKey
@@ -71,6 +100,8 @@ var (
             output signal nullRoot;
             output signal commitmentRoot;
             output signal commitmentHash;
+            // tuple elements following [value, scope, secret.x, secret.y]
+            output signal data[tupleLen - 4];
 
             //  [publicKey, secretKey, encryptionKey]
             var (
@@ -96,13 +127,33 @@ var (
                     encryptionKey[0], encryptionKey[1]
                 ]);
 
-            //  [value, scope, secret.x, secret.y]
-            var (tuple[tupleLen],hash) = DecryptCommitment(cipherLen, tupleLen)(
+            //  [value, scope, secret.x, secret.y, ...]
+            var (recovered[tupleLen],hash) = DecryptCommitment(cipherLen, tupleLen, checked)(
                     encryptionKey, nonce, ciphertext
                 );
 
-            value <== tuple[0];
+            value <== recovered[0];
             commitmentHash <== hash;
+            for (var i = 4; i < tupleLen; i++) {
+                data[i - 4] <== recovered[i];
+            }
+
+            // the commitment tree has the ciphertext
+            // & hash as leaves, zero padded to a power of 2
+            var levels = 1;
+            while (2 ** levels < cipherLen + 1) {
+                levels++;
+            }
+            var commitmentLeaves[2 ** levels];
+            for (var i = 0; i < 2 ** levels; i++) {
+                if (i < cipherLen) {
+                    commitmentLeaves[i] = ciphertext[i];
+                } else if (i == cipherLen) {
+                    commitmentLeaves[i] = hash;
+                } else {
+                    commitmentLeaves[i] = 0;
+                }
+            }
 
             // Verify contents and
             // Compute commitment root
@@ -114,17 +165,11 @@ var (
                     secret_yEqCheck,
                     computedCommitmentRoot
                 ) = (
-                    IsEqual()([scope, tuple[1]]),        // match scope
-                    IsEqual()([secretKey[0],tuple[2]]), // match secret.x component
-                    IsEqual()([secretKey[1],tuple[3]]), // match secret.y component
+                    IsEqual()([scope, recovered[1]]),        // match scope
+                    IsEqual()([secretKey[0],recovered[2]]), // match secret.x component
+                    IsEqual()([secretKey[1],recovered[3]]), // match secret.y component
                     // compute commitment root
-                    ComputeMerkleTreeRoot(3)(
-                    [
-                        ciphertext[0], ciphertext[1],
-                        ciphertext[2], ciphertext[3],
-                        ciphertext[4], ciphertext[5],
-                        ciphertext[6], hash
-                    ])
+                    ComputeMerkleTreeRoot(levels)(commitmentLeaves)
                 );
 
             // invalidate the root if ownership is invalid
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/bit"
+	"github.com/0xBow-io/privacy-pool-veritas/common/comparators"
+	"github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+	"github.com/0xBow-io/privacy-pool-veritas/common/logic"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/common/multiplexer"
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+	"github.com/0xBow-io/privacy-pool-veritas/common/scalar"
+	"github.com/0xBow-io/privacy-pool-veritas/common/utils"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+// coreCircuitPkg bundles the core circuit blocks
+// with all the common pkgs they depend on
+var coreCircuitPkgs = []CircuitPkg{
+	{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs: []Program{
+			RecoverCommitmentKeys,
+			DecryptCommitment,
+			CommitmentOwnershipProof,
+			CommitmentMembershipProof,
+			CountedValue,
+			HandleExistingCommitment,
+			HandleNewCommitment,
+			HandleExistingAssetCommitment,
+			HandleNewAssetCommitment,
+			DistinctNullRoots,
+			VALUE_BITS,
+			PoseidonDecryptWithoutCheck,
+			PoseidonDecryptTemplate,
+			PoseidonDecryptIterations,
+		},
+	},
+	babyjub.BabyJubCircuitPkg,
+	babyjub.MontGomeryCircuitPkg,
+	poseidon.PoseidonCircuitPkg,
+	bit.BinSumCircuitPkg,
+	bit.BitifyCircuitPkg,
+	comparators.ComparatorsCircuitPkg,
+	comparators.SafeComparatorsCircuitPkg,
+	ecdh.EcdhCircuitPkg,
+	logic.GatesCircuitPkg,
+	merkletree.MerkleTreeCircuitPkg,
+	multiplexer.MultiplexerCircuitPkg,
+	scalar.EscalarMulCircuitPkg,
+	utils.CircuitUtilsPkg,
+}
+
+func compileCore(t *testing.T, main string) CircuitLibrary {
+	lib := NewEmptyLibrary()
+	reports, err := lib.Compile(append([]CircuitPkg{
+		{
+			TargetVersion: "2.2.0",
+			Field:         "bn128",
+			Programs:      []Program{{Identity: "main", Src: main}},
+		},
+	}, coreCircuitPkgs...)...)
+	require.Nil(t, err)
+	require.False(t, harness.HasErrors(reports), reports.String())
+	return lib
+}
+
+func randomPrivateKey(t *testing.T) *big.Int {
+	v, err := rand.Int(rand.Reader, babyjub.SubOrder)
+	require.Nil(t, err)
+	return v
+}
+
+func randomCommitment(t *testing.T, scope, value *big.Int) *Commitment {
+	commitment, err := NewCommitment(scope, value, randomPrivateKey(t), randomPrivateKey(t), randomNonce(t))
+	require.Nil(t, err)
+	return commitment
+}
+
+func Test_NewCommitment(t *testing.T) {
+	var (
+		scope      = randomElement(t)
+		commitment = randomCommitment(t, scope, big.NewInt(1000))
+	)
+	require.Len(t, commitment.Ciphertext, 7)
+	require.True(t, commitment.PublicKey.InCurve())
+	require.True(t, commitment.SecretKey.InCurve())
+	require.True(t, commitment.EncryptionKey.InCurve())
+
+	// ECDH is symmetric, the salt owner derives the same encryption key
+	saltPrivateKey := randomPrivateKey(t)
+	c, err := NewCommitment(scope, big.NewInt(1), commitment.PrivateKey, saltPrivateKey, big.NewInt(0))
+	require.Nil(t, err)
+	shared, err := ecdh.SharedKey(saltPrivateKey, c.PublicKey)
+	require.Nil(t, err)
+	require.True(t, shared.Equal(c.EncryptionKey))
+
+	decrypted, err := PoseidonDecrypt(
+		commitment.Ciphertext,
+		[2]*big.Int{commitment.EncryptionKey.X, commitment.EncryptionKey.Y},
+		commitment.Nonce,
+		TupleLen,
+	)
+	require.Nil(t, err)
+	require.Equal(t, commitment.Tuple, decrypted)
+
+	_, err = NewCommitment(scope, big.NewInt(1), babyjub.SubOrder, big.NewInt(1), big.NewInt(0))
+	require.NotNil(t, err)
+}
+
+// Compare Go built commitments against CommitmentOwnershipProof
+func Test_NewCommitment_Circuit(t *testing.T) {
+	lib := compileCore(t, "component main {public[scope, saltPublicKey, ciphertext]} = CommitmentOwnershipProof(7, 4, 0);")
+	defer lib.Burn()
+
+	for i := 0; i < 3; i++ {
+		var (
+			scope      = randomElement(t)
+			commitment = randomCommitment(t, scope, big.NewInt(int64(i*100)))
+			input      = func(scope *big.Int) []byte {
+				return []byte(fmt.Sprintf(
+					`{"scope":"%s","privateKey":"%s","saltPublicKey":["%s","%s"],"nonce":"%s","ciphertext":%s}`,
+					scope, commitment.PrivateKey,
+					commitment.SaltPublicKey.X, commitment.SaltPublicKey.Y,
+					commitment.Nonce, jsonArray(commitment.Ciphertext),
+				))
+			}
+		)
+
+		evaluation, err := lib.Evaluate(input(scope))
+		require.Nil(t, err)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+		require.Equal(t, commitment.Value, harness.Signal(t, evaluation, "main.value"))
+		require.Equal(t, commitment.NullRoot, harness.Signal(t, evaluation, "main.nullRoot"))
+		require.Equal(t, commitment.CommitmentHash, harness.Signal(t, evaluation, "main.commitmentHash"))
+		require.Equal(t, commitment.CommitmentRoot, harness.Signal(t, evaluation, "main.commitmentRoot"))
+
+		// ownership is invalidated under a different scope
+		evaluation, err = lib.Evaluate(input(new(big.Int).Add(scope, big.NewInt(1))))
+		require.Nil(t, err)
+		require.Equal(t, big.NewInt(0), harness.Signal(t, evaluation, "main.commitmentRoot"))
+	}
+}
+
+// CommitmentOwnershipProof with checked = 1 fails on unauthenticated
+// ciphertexts, the unchecked mode only alters the commitmentRoot
+func Test_CommitmentOwnershipProof_Checked(t *testing.T) {
+	var (
+		scope       = randomElement(t)
+		commitment  = randomCommitment(t, scope, big.NewInt(100))
+		unauthentic = append([]*big.Int{}, commitment.Ciphertext...)
+		last        = len(unauthentic) - 1
+	)
+	unauthentic[last] = new(big.Int).Add(unauthentic[last], big.NewInt(1))
+	input := func(ciphertext []*big.Int) []byte {
+		return []byte(fmt.Sprintf(
+			`{"scope":"%s","privateKey":"%s","saltPublicKey":["%s","%s"],"nonce":"%s","ciphertext":%s}`,
+			scope, commitment.PrivateKey,
+			commitment.SaltPublicKey.X, commitment.SaltPublicKey.Y,
+			commitment.Nonce, jsonArray(ciphertext),
+		))
+	}
+
+	for _, checked := range []int{0, 1} {
+		lib := compileCore(t, fmt.Sprintf("component main = CommitmentOwnershipProof(7, 4, %d);", checked))
+
+		evaluation, err := lib.Evaluate(input(commitment.Ciphertext))
+		require.Nil(t, err)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0, "checked=%d", checked)
+		require.Equal(t, commitment.CommitmentRoot, harness.Signal(t, evaluation, "main.commitmentRoot"))
+
+		evaluation, err = lib.Evaluate(input(unauthentic))
+		require.Nil(t, err)
+		if checked == 1 {
+			require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()))
+		} else {
+			require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+			require.NotEqual(t, commitment.CommitmentRoot, harness.Signal(t, evaluation, "main.commitmentRoot"))
+		}
+		lib.Burn()
+	}
+}
+
+// CommitmentOwnershipProof derives the ciphertext length
+// & commitment tree padding from the tuple length
+func Test_CommitmentOwnershipProof_TupleLen(t *testing.T) {
+	for _, tupleLen := range []int{4, 5, 7, 10} {
+		cipherLen := CiphertextLength(tupleLen)
+		lib := compileCore(t, fmt.Sprintf(
+			"component main {public[scope, saltPublicKey, ciphertext]} = CommitmentOwnershipProof(%d, %d, 0);",
+			cipherLen, tupleLen,
+		))
+
+		scope := randomElement(t)
+		commitment, err := NewCommitment(scope, big.NewInt(1000), randomPrivateKey(t), randomPrivateKey(t), randomNonce(t),
+			randomElements(t, tupleLen-TupleLen)...)
+		require.Nil(t, err)
+		require.Len(t, commitment.Tuple, tupleLen)
+		require.Len(t, commitment.Ciphertext, cipherLen)
+
+		evaluation, err := lib.Evaluate([]byte(fmt.Sprintf(
+			`{"scope":"%s","privateKey":"%s","saltPublicKey":["%s","%s"],"nonce":"%s","ciphertext":%s}`,
+			scope, commitment.PrivateKey,
+			commitment.SaltPublicKey.X, commitment.SaltPublicKey.Y,
+			commitment.Nonce, jsonArray(commitment.Ciphertext),
+		)))
+		require.Nil(t, err)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0, "tupleLen %d", tupleLen)
+
+		require.Equal(t, commitment.Value, harness.Signal(t, evaluation, "main.value"))
+		require.Equal(t, commitment.CommitmentHash, harness.Signal(t, evaluation, "main.commitmentHash"))
+		require.Equal(t, commitment.CommitmentRoot, harness.Signal(t, evaluation, "main.commitmentRoot"))
+		lib.Burn()
+	}
+}
+
+// Inconsistent cipherLen/tupleLen pairs & decryption modes
+// are rejected at compile time
+func Test_CommitmentOwnershipProof_InvalidParams(t *testing.T) {
+	for _, params := range [][3]int{{8, 4, 0}, {7, 7, 0}, {4, 3, 0}, {7, 4, 2}} {
+		lib := NewEmptyLibrary()
+		reports, err := lib.Compile(append([]CircuitPkg{{
+			TargetVersion: "2.2.0",
+			Field:         "bn128",
+			Programs: []Program{{
+				Identity: "main",
+				Src:      fmt.Sprintf("component main = CommitmentOwnershipProof(%d, %d, %d);", params[0], params[1], params[2]),
+			}},
+		}}, coreCircuitPkgs...)...)
+
+		require.True(t, err != nil || harness.HasErrors(reports), "CommitmentOwnershipProof(%d, %d, %d) compiled", params[0], params[1], params[2])
+		lib.Burn()
+	}
+}
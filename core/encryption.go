@@ -0,0 +1,159 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+)
+
+// Native (Go) counterpart of PoseidonDecryptIterations.
+// Follows the same sponge layout as the circuit:
+//
+//	state[0] = [0, key[0], key[1], nonce + l * 2^128]
+//	ciphertext[i*3+j] = decrypted[i*3+j] + Permute(state[i])[j+1]
+//	state[i+1] = [Permute(state[i])[0], ciphertext[i*3], ciphertext[i*3+1], ciphertext[i*3+2]]
+//	ciphertext[last] = Permute(state[n])[1]
+//
+// where the message is zero padded to a multiple of 3
+// and the trailing ciphertext element authenticates the message.
+
+var (
+	two128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+	ErrInvalidNonce            = errors.New("nonce must be less than 2^128")
+	ErrInvalidKey              = errors.New("key must contain 2 field elements")
+	ErrInvalidCiphertextLength = errors.New("ciphertext length does not match the message length")
+	ErrAuthenticationFailed    = errors.New("ciphertext authentication failed")
+	ErrInvalidPadding          = errors.New("ciphertext padding is not zero")
+)
+
+// CiphertextLength returns the ciphertext length produced
+// for a message of length l, i.e. l padded to a multiple of 3
+// plus the trailing authentication element
+func CiphertextLength(l int) int {
+	return paddedLength(l) + 1
+}
+
+func paddedLength(l int) int {
+	for l%3 != 0 {
+		l++
+	}
+	return l
+}
+
+// PoseidonEncrypt encrypts the tuple with the shared key & nonce
+// producing a ciphertext of CiphertextLength(len(tuple)) elements
+func PoseidonEncrypt(tuple []*big.Int, key [2]*big.Int, nonce *big.Int) ([]*big.Int, error) {
+	message := make([]*big.Int, paddedLength(len(tuple)))
+	for i := range message {
+		message[i] = big.NewInt(0)
+		if i < len(tuple) {
+			if tuple[i] == nil {
+				return nil, fmt.Errorf("tuple[%d] is nil", i)
+			}
+			message[i] = field.Reduce(tuple[i])
+		}
+	}
+	return poseidonEncryptIterations(message, len(tuple), key, nonce)
+}
+
+// poseidonEncryptIterations encrypts an already padded message
+// of length l (the padding isn't required to be zero)
+func poseidonEncryptIterations(message []*big.Int, l int, key [2]*big.Int, nonce *big.Int) ([]*big.Int, error) {
+	state, err := initialCipherState(l, key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]*big.Int, 0, len(message)+1)
+	for i := 0; i < len(message)/3; i++ {
+		if state, err = poseidon.Permute(state); err != nil {
+			return nil, err
+		}
+		for j := 0; j < 3; j++ {
+			state[j+1] = field.Reduce(new(big.Int).Add(state[j+1], message[i*3+j]))
+			ciphertext = append(ciphertext, state[j+1])
+		}
+	}
+
+	if state, err = poseidon.Permute(state); err != nil {
+		return nil, err
+	}
+	return append(ciphertext, state[1]), nil
+}
+
+// PoseidonDecrypt decrypts a ciphertext of a message with the given length
+// and verifies the trailing authentication element & the zero padding.
+// Mirrors the checks of the PoseidonDecrypt(length) template (PoseidonDecryptTemplate)
+func PoseidonDecrypt(ciphertext []*big.Int, key [2]*big.Int, nonce *big.Int, length int) ([]*big.Int, error) {
+	decrypted, last, err := poseidonDecryptIterations(ciphertext, key, nonce, length)
+	if err != nil {
+		return nil, err
+	}
+	if last.Cmp(ciphertext[len(ciphertext)-1]) != 0 {
+		return nil, ErrAuthenticationFailed
+	}
+	for _, x := range decrypted[length:] {
+		if x.Sign() != 0 {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return decrypted[:length], nil
+}
+
+// poseidonDecryptIterations mirrors PoseidonDecryptIterations(l)
+// returning (decrypted, decryptedLast)
+func poseidonDecryptIterations(ciphertext []*big.Int, key [2]*big.Int, nonce *big.Int, l int) ([]*big.Int, *big.Int, error) {
+	if l < 1 || len(ciphertext) != CiphertextLength(l) {
+		return nil, nil, ErrInvalidCiphertextLength
+	}
+	state, err := initialCipherState(l, key, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		n         = paddedLength(l) / 3
+		decrypted = make([]*big.Int, 0, paddedLength(l))
+	)
+	for i := 0; i < n; i++ {
+		if state, err = poseidon.Permute(state); err != nil {
+			return nil, nil, err
+		}
+		for j := 0; j < 3; j++ {
+			c := ciphertext[i*3+j]
+			if c == nil {
+				return nil, nil, fmt.Errorf("ciphertext[%d] is nil", i*3+j)
+			}
+			decrypted = append(decrypted, field.Reduce(new(big.Int).Sub(c, state[j+1])))
+			state[j+1] = field.Reduce(c)
+		}
+	}
+
+	if state, err = poseidon.Permute(state); err != nil {
+		return nil, nil, err
+	}
+	return decrypted, state[1], nil
+}
+
+func initialCipherState(l int, key [2]*big.Int, nonce *big.Int) ([]*big.Int, error) {
+	if key[0] == nil || key[1] == nil {
+		return nil, ErrInvalidKey
+	}
+	if nonce == nil || nonce.Sign() < 0 || nonce.Cmp(two128) >= 0 {
+		return nil, ErrInvalidNonce
+	}
+	// nonce + (l * 2^128)
+	seed := new(big.Int).Mul(big.NewInt(int64(l)), two128)
+	seed.Add(seed, nonce)
+
+	return []*big.Int{
+		big.NewInt(0),
+		field.Reduce(key[0]),
+		field.Reduce(key[1]),
+		field.Reduce(seed),
+	}, nil
+}
@@ -2,16 +2,42 @@ package core
 
 import (
 	_ "embed"
+	"fmt"
 
 	. "github.com/0xBow-io/veritas"
 )
 
 // TODO: Add Documentation
 var (
+	// VALUE_BITS returns ValueBits, the bit width
+	// the top-level templates range check values to
+	VALUE_BITS = Program{
+		Identity: "VALUE_BITS",
+		Src:      fmt.Sprintf("function VALUE_BITS() { return %d; }", ValueBits),
+	}
+
+	// CountedValue nulls the value of an invalid commitment
+	// & range checks the counted value to valueBits bits
+	// so that the sums of values can't wrap around the field
+	CountedValue = Program{
+		Identity: "CountedValue",
+		Src: `
+		template CountedValue(valueBits){
+            assert(valueBits > 0 && valueBits <= 252);
+
+            input signal value;
+            input signal isInvalid;
+            output signal out;
+
+            out <== value * (1 - isInvalid);
+            var n2bValue[valueBits] = Num2Bits(valueBits)(out);
+        }
+	`}
+
 	HandleExistingCommitment = Program{
 		Identity: "HandleExistingCommitment",
 		Src: `
-		template HandleExistingCommitment(maxTreeDepth, cipherLen, tupleLen){
+		template HandleExistingCommitment(maxTreeDepth, cipherLen, tupleLen, valueBits, checked){
             input signal scope, stateRoot, actualTreeDepth;
             input signal privateKey, nonce;
             input signal saltPublicKey[2], ciphertext[cipherLen];
@@ -21,7 +47,7 @@ var (
             // into 1 array output signal
             output signal out[4];
 
-            var (value, nullRoot, commitmentRoot, hash) = CommitmentOwnershipProof(cipherLen, tupleLen)(
+            var (value, nullRoot, commitmentRoot, hash, data[tupleLen - 4]) = CommitmentOwnershipProof(cipherLen, tupleLen, checked)(
                 scope, privateKey, saltPublicKey, nonce, ciphertext
             );
 
@@ -37,14 +63,60 @@ var (
             out[0] <== nullRoot;
             out[1] <== commitmentRoot * isInvalid;
             out[2] <== hash * isInvalid;
-            out[3] <== value * ( 1- isInvalid);
+            out[3] <== CountedValue(valueBits)(value, isInvalid);
+        }
+	`}
+
+	// HandleAssociatedCommitment extends HandleExistingCommitment
+	// with a membership proof of the commitmentRoot in the association tree.
+	// The commitment is invalid (value nulled) unless it is void
+	// or both the state & association membership proofs hold.
+	HandleAssociatedCommitment = Program{
+		Identity: "HandleAssociatedCommitment",
+		Src: `
+		template HandleAssociatedCommitment(maxTreeDepth, maxAssociationTreeDepth, cipherLen, tupleLen, valueBits, checked){
+            input signal scope, stateRoot, actualTreeDepth;
+            input signal associationRoot, actualAssociationTreeDepth;
+            input signal privateKey, nonce;
+            input signal saltPublicKey[2], ciphertext[cipherLen];
+            input signal index, siblings[maxTreeDepth];
+            input signal associationIndex, associationSiblings[maxAssociationTreeDepth];
+
+            // aggregate (nullRoot, commitmentRoot, hash, value)
+            // into 1 array output signal
+            output signal out[4];
+
+            var (value, nullRoot, commitmentRoot, hash, data[tupleLen - 4]) = CommitmentOwnershipProof(cipherLen, tupleLen, checked)(
+                scope, privateKey, saltPublicKey, nonce, ciphertext
+            );
+
+            var computedStateRoot = CommitmentMembershipProof(maxTreeDepth)(
+                actualTreeDepth, commitmentRoot, index, siblings
+            );
+
+            // the association tree leaf is the commitmentRoot
+            var computedAssociationRoot = CommitmentMembershipProof(maxAssociationTreeDepth)(
+                actualAssociationTreeDepth, commitmentRoot, associationIndex, associationSiblings
+            );
+
+            var isVoidCheck = IsZero()(value);
+            var stateRootEqCheck = IsZero()(computedStateRoot - stateRoot);
+            var associationRootEqCheck = IsZero()(computedAssociationRoot - associationRoot);
+
+            signal isMember <== AND()(stateRootEqCheck, associationRootEqCheck);
+            signal isInvalid <== NOR()(isVoidCheck, isMember);
+
+            out[0] <== nullRoot;
+            out[1] <== commitmentRoot * isInvalid;
+            out[2] <== hash * isInvalid;
+            out[3] <== CountedValue(valueBits)(value, isInvalid);
         }
 	`}
 
 	HandleNewCommitment = Program{
 		Identity: "HandleNewCommitment",
 		Src: `
-		template HandleNewCommitment(cipherLen, tupleLen){
+		template HandleNewCommitment(cipherLen, tupleLen, valueBits, checked){
             input signal scope;
             input signal privateKey, nonce;
             input signal saltPublicKey[2], ciphertext[cipherLen];
@@ -53,7 +125,7 @@ var (
             // into 1 array output signal
             output signal out[4];
 
-            var (value, nullRoot, commitmentRoot, hash) = CommitmentOwnershipProof(cipherLen, tupleLen)(
+            var (value, nullRoot, commitmentRoot, hash, data[tupleLen - 4]) = CommitmentOwnershipProof(cipherLen, tupleLen, checked)(
                 scope, privateKey, saltPublicKey, nonce, ciphertext
             );
 
@@ -66,7 +138,114 @@ var (
             out[1] <== commitmentRoot;
             out[2] <== hash;
             // null value if commitmentRoot is invalid
-            out[3] <== value * (1 - invalidCommitmentRootCheck);
+            out[3] <== CountedValue(valueBits)(value, invalidCommitmentRootCheck);
+        }
+		`,
+	}
+
+	// HandleExistingAssetCommitment is HandleExistingCommitment
+	// for asset tagged commitments:
+	// [value, scope, secret.x, secret.y, assetId, ...]
+	// The assetId is output alongside the value.
+	HandleExistingAssetCommitment = Program{
+		Identity: "HandleExistingAssetCommitment",
+		Src: `
+		template HandleExistingAssetCommitment(maxTreeDepth, cipherLen, tupleLen, valueBits, checked){
+            assert(tupleLen >= 5);
+
+            input signal scope, stateRoot, actualTreeDepth;
+            input signal privateKey, nonce;
+            input signal saltPublicKey[2], ciphertext[cipherLen];
+            input signal index, siblings[maxTreeDepth];
+
+            // aggregate (nullRoot, commitmentRoot, hash, value, assetId)
+            // into 1 array output signal
+            output signal out[5];
+
+            var (value, nullRoot, commitmentRoot, hash, data[tupleLen - 4]) = CommitmentOwnershipProof(cipherLen, tupleLen, checked)(
+                scope, privateKey, saltPublicKey, nonce, ciphertext
+            );
+
+            var computedStateRoot = CommitmentMembershipProof(maxTreeDepth)(
+                actualTreeDepth, commitmentRoot, index, siblings
+            );
+
+            var isVoidCheck = IsZero()(value);
+            var stateRootEqCheck = IsZero()(computedStateRoot - stateRoot);
+
+            signal isInvalid <== NOR()(isVoidCheck, stateRootEqCheck);
+
+            out[0] <== nullRoot;
+            out[1] <== commitmentRoot * isInvalid;
+            out[2] <== hash * isInvalid;
+            out[3] <== CountedValue(valueBits)(value, isInvalid);
+            out[4] <== data[0];
+        }
+	`}
+
+	// HandleNewAssetCommitment is HandleNewCommitment
+	// for asset tagged commitments:
+	// [value, scope, secret.x, secret.y, assetId, ...]
+	// The assetId is output alongside the value.
+	HandleNewAssetCommitment = Program{
+		Identity: "HandleNewAssetCommitment",
+		Src: `
+		template HandleNewAssetCommitment(cipherLen, tupleLen, valueBits, checked){
+            assert(tupleLen >= 5);
+
+            input signal scope;
+            input signal privateKey, nonce;
+            input signal saltPublicKey[2], ciphertext[cipherLen];
+
+            // aggregate (nullRoot, commitmentRoot, hash, value, assetId)
+            // into 1 array output signal
+            output signal out[5];
+
+            var (value, nullRoot, commitmentRoot, hash, data[tupleLen - 4]) = CommitmentOwnershipProof(cipherLen, tupleLen, checked)(
+                scope, privateKey, saltPublicKey, nonce, ciphertext
+            );
+
+            signal invalidCommitmentRootCheck <== IsZero()(commitmentRoot);
+
+            // If invalid ownership then commitmentRoot is 0
+            // don't stop the circuit.
+            // Output nullRoot if so, as this nullifies the new commitment.
+            out[0] <==  nullRoot * invalidCommitmentRootCheck;
+            out[1] <== commitmentRoot;
+            out[2] <== hash;
+            // null value if commitmentRoot is invalid
+            out[3] <== CountedValue(valueBits)(value, invalidCommitmentRootCheck);
+            out[4] <== data[0];
+        }
+		`,
+	}
+
+	// DistinctNullRoots constrains the nullRoots of commitments
+	// to be pairwise distinct, except for void (zero value) ones,
+	// so that the same commitment can't be counted twice
+	DistinctNullRoots = Program{
+		Identity: "DistinctNullRoots",
+		Src: `
+		template DistinctNullRoots(n){
+            input signal nullRoot[n];
+            input signal value[n];
+
+            var isVoid[n];
+            for (var i = 0; i < n; i++) {
+                isVoid[i] = IsZero()(value[i]);
+            }
+
+            // one signal per (i, j) pair with i < j
+            signal isActive[n * (n - 1) \ 2];
+            var pair = 0;
+            for (var i = 0; i < n; i++) {
+                for (var j = i + 1; j < n; j++) {
+                    var isDuplicate = IsEqual()([nullRoot[i], nullRoot[j]]);
+                    isActive[pair] <== (1 - isVoid[i]) * (1 - isVoid[j]);
+                    isActive[pair] * isDuplicate === 0;
+                    pair++;
+                }
+            }
         }
 		`,
 	}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+)
+
+var ErrInvalidScanner = errors.New("invalid scanner")
+
+// PublishedCommitment is a new commitment as published by the pool
+// (newSaltPublicKey & newCiphertext), Index is its position in the stream
+type PublishedCommitment struct {
+	Index         int
+	SaltPublicKey *babyjub.Point
+	Ciphertext    []*big.Int
+}
+
+// ScanResult is a published commitment owned by the scanner key
+type ScanResult struct {
+	Index      int
+	Commitment *Commitment
+}
+
+// Scanner discovers the commitments owned by PrivateKey
+// by trial decryption of published commitments.
+// A commitment matches when its ciphertext decrypts & authenticates
+// under one of the candidate nonces and the tuple holds Scope
+// & the secret of PrivateKey (as checked by CommitmentOwnershipProof)
+type Scanner struct {
+	PrivateKey *big.Int
+	Scope      *big.Int
+	TupleLen   int
+	// Nonces returns the candidate nonces of a published commitment,
+	// the nonce is a private input and isn't published
+	Nonces func(c PublishedCommitment) []*big.Int
+	// Workers is the number of concurrent decryptions,
+	// defaults to runtime.NumCPU()
+	Workers int
+}
+
+// FixedNonces tries the same nonces on every published commitment
+func FixedNonces(nonces ...*big.Int) func(PublishedCommitment) []*big.Int {
+	return func(PublishedCommitment) []*big.Int { return nonces }
+}
+
+// Scan trial decrypts every commitment received from published
+// until it is closed or ctx is done.
+// Matches are returned ordered by Index
+func (s *Scanner) Scan(ctx context.Context, published <-chan PublishedCommitment) ([]ScanResult, error) {
+	if s.PrivateKey == nil || s.Scope == nil || s.Nonces == nil {
+		return nil, fmt.Errorf("%w: privateKey, scope & nonces are required", ErrInvalidScanner)
+	}
+	if s.TupleLen < TupleLen {
+		return nil, fmt.Errorf("%w: tuple length %d < %d", ErrInvalidScanner, s.TupleLen, TupleLen)
+	}
+	publicKey, err := babyjub.PrivToPub(s.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScanner, err)
+	}
+	// the secret only depends on the private key
+	secretKey, err := ecdh.SharedKey(s.PrivateKey, publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScanner, err)
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []ScanResult
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case c, ok := <-published:
+					if !ok {
+						return
+					}
+					if commitment := s.open(c, secretKey); commitment != nil {
+						mu.Lock()
+						results = append(results, ScanResult{Index: c.Index, Commitment: commitment})
+						mu.Unlock()
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	return results, nil
+}
+
+// ScanAll scans a slice of published commitments (see Scan)
+func (s *Scanner) ScanAll(ctx context.Context, published []PublishedCommitment) ([]ScanResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream := make(chan PublishedCommitment)
+	go func() {
+		defer close(stream)
+		for _, c := range published {
+			select {
+			case stream <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return s.Scan(ctx, stream)
+}
+
+// open returns the commitment if c is owned by the scanner key,
+// malformed commitments are not owned by anyone
+func (s *Scanner) open(c PublishedCommitment, secretKey *babyjub.Point) *Commitment {
+	if c.SaltPublicKey == nil || len(c.Ciphertext) != CiphertextLength(s.TupleLen) {
+		return nil
+	}
+	encryptionKey, err := ecdh.SharedKey(s.PrivateKey, c.SaltPublicKey)
+	if err != nil {
+		return nil
+	}
+	key := [2]*big.Int{encryptionKey.X, encryptionKey.Y}
+	for _, nonce := range s.Nonces(c) {
+		tuple, err := PoseidonDecrypt(c.Ciphertext, key, nonce, s.TupleLen)
+		if err != nil {
+			continue
+		}
+		//  [value, scope, secret.x, secret.y, data...]
+		if tuple[1].Cmp(s.Scope) != 0 ||
+			tuple[2].Cmp(secretKey.X) != 0 || tuple[3].Cmp(secretKey.Y) != 0 {
+			continue
+		}
+		commitment, err := OpenCommitment(s.PrivateKey, c.SaltPublicKey, nonce, c.Ciphertext, s.TupleLen)
+		if err != nil {
+			continue
+		}
+		return commitment
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/test-go/testify/require"
+)
+
+func Test_Scanner(t *testing.T) {
+	var (
+		scope      = randomElement(t)
+		privateKey = randomPrivateKey(t)
+		nonce      = randomNonce(t)
+		published  []PublishedCommitment
+		expected   []int
+	)
+	publish := func(c *Commitment, owned bool) {
+		if owned {
+			expected = append(expected, len(published))
+		}
+		published = append(published, PublishedCommitment{
+			Index:         len(published),
+			SaltPublicKey: c.SaltPublicKey,
+			Ciphertext:    c.Ciphertext,
+		})
+	}
+	owned := func(scope *big.Int, nonce *big.Int) *Commitment {
+		c, err := NewCommitment(scope, big.NewInt(int64(len(published)+1)), privateKey, randomPrivateKey(t), nonce)
+		require.Nil(t, err)
+		return c
+	}
+
+	for i := 0; i < 20; i++ {
+		switch i % 5 {
+		case 0:
+			publish(owned(scope, nonce), true)
+		case 1:
+			// another owner
+			c, err := NewCommitment(scope, big.NewInt(1), randomPrivateKey(t), randomPrivateKey(t), nonce)
+			require.Nil(t, err)
+			publish(c, false)
+		case 2:
+			// another scope
+			publish(owned(randomElement(t), nonce), false)
+		case 3:
+			// unknown nonce
+			publish(owned(scope, randomNonce(t)), false)
+		case 4:
+			// malformed
+			c := owned(scope, nonce)
+			publish(&Commitment{SaltPublicKey: c.SaltPublicKey, Ciphertext: c.Ciphertext[1:]}, false)
+		}
+	}
+
+	for _, workers := range []int{0, 1, 4} {
+		scanner := &Scanner{
+			PrivateKey: privateKey,
+			Scope:      scope,
+			TupleLen:   TupleLen,
+			Nonces:     FixedNonces(big.NewInt(0), nonce),
+			Workers:    workers,
+		}
+		results, err := scanner.ScanAll(context.Background(), published)
+		require.Nil(t, err)
+		require.Len(t, results, len(expected))
+		for i, r := range results {
+			require.Equal(t, expected[i], r.Index)
+			require.Equal(t, int64(r.Index+1), r.Commitment.Value.Int64())
+			require.Equal(t, scope, r.Commitment.Scope)
+			require.Equal(t, published[r.Index].Ciphertext, r.Commitment.Ciphertext)
+		}
+	}
+
+	// cancelled scan
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scanner := &Scanner{PrivateKey: privateKey, Scope: scope, TupleLen: TupleLen, Nonces: FixedNonces(nonce)}
+	_, err := scanner.ScanAll(ctx, published)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	// invalid scanner
+	_, err = (&Scanner{PrivateKey: privateKey, Scope: scope, TupleLen: 3, Nonces: FixedNonces(nonce)}).ScanAll(context.Background(), published)
+	require.True(t, errors.Is(err, ErrInvalidScanner))
+	_, err = (&Scanner{PrivateKey: privateKey, TupleLen: TupleLen, Nonces: FixedNonces(nonce)}).ScanAll(context.Background(), published)
+	require.True(t, errors.Is(err, ErrInvalidScanner))
+}
@@ -0,0 +1,46 @@
+package privacypool
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PrivacyPoolDepositInputs holds every input signal of PrivacyPoolDeposit,
+// i.e. PrivacyPoolInputs without existing commitments
+// and with externIO[0] as the externInput
+type PrivacyPoolDepositInputs struct {
+	PrivacyPoolInputs
+}
+
+// BuildDeposit is Build for PrivacyPoolDeposit(cipherLen, tupleLen, nNew),
+// the builder params must have no existing commitment,
+// externIO[1] & the state tree are left unset (or zero)
+func (b *PrivacyPoolInputsBuilder) BuildDeposit() (*PrivacyPoolDepositInputs, error) {
+	if b.params.NExisting != 0 {
+		return nil, fmt.Errorf("%w: deposit expects no existing commitment, params have %d", ErrInvalidInputs, b.params.NExisting)
+	}
+	if v := b.inputs.ExternIO[1]; v != nil && v.Sign() != 0 {
+		return nil, fmt.Errorf("%w: externIO[1] must be zero for a deposit", ErrInvalidInputs)
+	}
+	for _, v := range []*big.Int{b.inputs.ActualTreeDepth, b.inputs.ExistingStateRoot} {
+		if v != nil && v.Sign() != 0 {
+			return nil, fmt.Errorf("%w: the state tree must be unset for a deposit", ErrInvalidInputs)
+		}
+	}
+	b.ExternIO(b.inputs.ExternIO[0], big.NewInt(0))
+	b.StateTree(big.NewInt(0), 0)
+	inputs, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &PrivacyPoolDepositInputs{PrivacyPoolInputs: *inputs}, nil
+}
+
+// MarshalJSON encodes the inputs as the input JSON of PrivacyPoolDeposit
+// (see PrivacyPoolInputs.MarshalJSON)
+func (in PrivacyPoolDepositInputs) MarshalJSON() ([]byte, error) {
+	return marshalSignals(in.PrivacyPoolInputs,
+		[]string{"actualTreeDepth", "externIO", "existingStateRoot", "exSaltPublicKey", "exCiphertext", "exIndex", "exSiblings"},
+		map[string][]string{"externInput": toDecimals(in.ExternIO[0])},
+	)
+}
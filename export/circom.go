@@ -0,0 +1,215 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
+	. "github.com/0xBow-io/veritas"
+)
+
+var (
+	ErrNoPkgs             = errors.New("no circuit pkgs to export")
+	ErrPkgMismatch        = errors.New("circuit pkgs target different versions or fields")
+	ErrDuplicateProgram   = errors.New("program defined more than once with different sources")
+	ErrCircularDependency = errors.New("circular program dependency")
+)
+
+// program is a program to be exported along with the name of
+// the registered circuit pkg bundling it ("" if there is none).
+// Circuit pkgs may bundle programs defined by other Go packages
+// (i.e. privacypool.PrivacyPoolCircuitPkg bundles the core templates)
+type program struct {
+	Program
+	pkgName   string
+	templates map[string]string
+}
+
+// Circom writes main and the programs of pkgs as a single
+// self-contained circom source to w.
+//
+// Programs are de-duplicated by identity and written in stable
+// dependency order (dependencies first, ties broken by the
+// circuit pkg name & program identity), each preceded by a
+// comment naming the registered circuit pkg bundling it.
+func Circom(w io.Writer, main Program, pkgs ...CircuitPkg) error {
+	if len(pkgs) == 0 {
+		return ErrNoPkgs
+	}
+	var (
+		version = pkgs[0].TargetVersion
+		field   = pkgs[0].Field
+	)
+	for i, pkg := range pkgs {
+		if pkg.TargetVersion != version || pkg.Field != field {
+			return fmt.Errorf("%w: pkg %d targets %s (%s) instead of %s (%s)",
+				ErrPkgMismatch, i, pkg.TargetVersion, pkg.Field, version, field)
+		}
+	}
+
+	programs, err := collect(pkgs)
+	if err != nil {
+		return err
+	}
+	ordered, err := order(programs)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pragma circom %s;\n\n", version)
+	fmt.Fprintf(&b, "// Code generated from Go circuit pkgs. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "// field: %s\n", field)
+	fmt.Fprintf(&b, "// programs: %d\n", len(ordered))
+	for _, p := range ordered {
+		if p.pkgName != "" {
+			fmt.Fprintf(&b, "\n// %s (bundled in %s)\n", strings.TrimSpace(p.Identity), p.pkgName)
+		} else {
+			fmt.Fprintf(&b, "\n// %s (not in a registered circuit pkg)\n", strings.TrimSpace(p.Identity))
+		}
+		b.WriteString(dedent(p.Src))
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\n// %s\n", strings.TrimSpace(main.Identity))
+	b.WriteString(dedent(main.Src))
+	b.WriteString("\n")
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// collect de-duplicates the programs of pkgs by identity
+func collect(pkgs []CircuitPkg) (map[string]*program, error) {
+	programs := make(map[string]*program)
+	for _, pkg := range pkgs {
+		for _, p := range pkg.Programs {
+			if existing, ok := programs[p.Identity]; ok {
+				if strings.TrimSpace(existing.Src) != strings.TrimSpace(p.Src) {
+					return nil, fmt.Errorf("%w: %s", ErrDuplicateProgram, p.Identity)
+				}
+				continue
+			}
+			pkgName, _ := registry.NameOf(p.Identity)
+			programs[p.Identity] = &program{
+				Program:   p,
+				pkgName:   pkgName,
+				templates: registry.Definitions(p.Src),
+			}
+		}
+	}
+	return programs, nil
+}
+
+// order sorts programs so that every program
+// comes after the programs it references
+func order(programs map[string]*program) ([]*program, error) {
+	var (
+		// template name -> defining program
+		owners = make(map[string]*program)
+		sorted = make([]*program, 0, len(programs))
+	)
+	for _, p := range programs {
+		sorted = append(sorted, p)
+		for name := range p.templates {
+			owners[name] = p
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].pkgName != sorted[j].pkgName {
+			return sorted[i].pkgName < sorted[j].pkgName
+		}
+		return sorted[i].Identity < sorted[j].Identity
+	})
+
+	// program -> referenced programs, in sorted order
+	deps := make(map[*program][]*program)
+	for _, p := range sorted {
+		seen := map[*program]bool{p: true}
+		for name, src := range p.templates {
+			for _, ref := range registry.References(name, src) {
+				if q, ok := owners[ref]; ok && !seen[q] {
+					seen[q] = true
+					deps[p] = append(deps[p], q)
+				}
+			}
+		}
+	}
+	rank := make(map[*program]int, len(sorted))
+	for i, p := range sorted {
+		rank[p] = i
+	}
+	for _, d := range deps {
+		sort.Slice(d, func(i, j int) bool { return rank[d[i]] < rank[d[j]] })
+	}
+
+	const (
+		visiting = iota + 1
+		done
+	)
+	var (
+		state = make(map[*program]int)
+		out   = make([]*program, 0, len(sorted))
+		visit func(p *program) error
+	)
+	visit = func(p *program) error {
+		switch state[p] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrCircularDependency, p.Identity)
+		case done:
+			return nil
+		}
+		state[p] = visiting
+		for _, q := range deps[p] {
+			if err := visit(q); err != nil {
+				return err
+			}
+		}
+		state[p] = done
+		out = append(out, p)
+		return nil
+	}
+	for _, p := range sorted {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// dedent trims src and removes the indentation
+// shared by all but its first line
+func dedent(src string) string {
+	lines := strings.Split(strings.TrimSpace(src), "\n")
+	if len(lines) < 2 {
+		return lines[0]
+	}
+	var prefix *string
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if prefix == nil {
+			prefix = &indent
+			continue
+		}
+		for !strings.HasPrefix(indent, *prefix) {
+			p := (*prefix)[:len(*prefix)-1]
+			prefix = &p
+		}
+	}
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		if prefix != nil {
+			line = strings.TrimPrefix(line, *prefix)
+		}
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+func testPkg(programs ...Program) CircuitPkg {
+	return CircuitPkg{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs:      programs,
+	}
+}
+
+var (
+	progA = Program{Identity: "A", Src: `
+		template A() {
+            signal input in;
+            signal output out <== in;
+        }`}
+	progB = Program{Identity: "B", Src: `
+		template B() {
+            signal input in;
+            signal output out <== A()(in);
+        }`}
+	progC = Program{Identity: "C", Src: `
+		template C() {
+            signal input in;
+            signal output out <== B()(in) + A()(in);
+        }`}
+	progMain = Program{Identity: "main", Src: "component main = C();"}
+)
+
+func Test_Circom(t *testing.T) {
+	var a, b bytes.Buffer
+	require.Nil(t, Circom(&a, progMain, testPkg(progC, progB), testPkg(progA, progC)))
+	require.Nil(t, Circom(&b, progMain, testPkg(progA), testPkg(progB, progC)))
+
+	// stable regardless of the pkg order
+	require.Equal(t, a.String(), b.String())
+
+	out := a.String()
+	require.True(t, strings.HasPrefix(out, "pragma circom 2.2.0;\n"))
+	require.Equal(t, 1, strings.Count(out, "template C()"))
+	require.True(t, strings.Index(out, "template A()") < strings.Index(out, "template B()"))
+	require.True(t, strings.Index(out, "template B()") < strings.Index(out, "template C()"))
+	require.Contains(t, out, "// A (not in a registered circuit pkg)\ntemplate A() {\n    signal input in;")
+	require.True(t, strings.HasSuffix(out, "// main\ncomponent main = C();\n"))
+}
+
+func Test_Circom_Errors(t *testing.T) {
+	var w bytes.Buffer
+	require.Equal(t, ErrNoPkgs, Circom(&w, progMain))
+
+	pkg := testPkg(progA)
+	pkg.TargetVersion = "2.1.0"
+	err := Circom(&w, progMain, testPkg(progB), pkg)
+	require.True(t, errors.Is(err, ErrPkgMismatch))
+
+	err = Circom(&w, progMain, testPkg(progA), testPkg(Program{Identity: "A", Src: progB.Src}))
+	require.True(t, errors.Is(err, ErrDuplicateProgram))
+
+	cycle := Program{Identity: "A", Src: `template A() { signal input in; signal output out <== C()(in); }`}
+	err = Circom(&w, progMain, testPkg(cycle, progB, progC))
+	require.True(t, errors.Is(err, ErrCircularDependency))
+}
@@ -0,0 +1,422 @@
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedValue = errors.New("unsupported signal value")
+
+// Circuit is the template under test
+// i.e. POSEIDON_STD(2) with Template "POSEIDON_STD" & Params [2]
+type Circuit struct {
+	Template string   `json:"template" yaml:"template"`
+	Params   []Value  `json:"params" yaml:"params"`
+	Public   []string `json:"public" yaml:"public"`
+
+	// Pkgs required to compile the template
+	Pkgs []CircuitPkg `json:"-" yaml:"-"`
+}
+
+// Case is a single test vector
+//
+// Inputs map input signals to values, Expected maps signals to their
+// expected values. Values are numbers, decimal or 0x-prefixed hex strings
+// or (nested) arrays of those. Signal names are relative to main
+// (i.e. "out") unless they contain a '.' (i.e. "main.hasher.out").
+// Fails expects at least one unsatisfied constraint
+type Case struct {
+	Name     string                 `json:"name" yaml:"name"`
+	Inputs   map[string]interface{} `json:"inputs" yaml:"inputs"`
+	Expected map[string]interface{} `json:"expected" yaml:"expected"`
+	Fails    bool                   `json:"fails" yaml:"fails"`
+}
+
+// UnmarshalYAML keeps scalars as Values
+// so that large numbers don't lose precision
+func (c *Case) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Name     string               `yaml:"name"`
+		Inputs   map[string]yaml.Node `yaml:"inputs"`
+		Expected map[string]yaml.Node `yaml:"expected"`
+		Fails    bool                 `yaml:"fails"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*c = Case{
+		Name:     raw.Name,
+		Inputs:   make(map[string]interface{}, len(raw.Inputs)),
+		Expected: make(map[string]interface{}, len(raw.Expected)),
+		Fails:    raw.Fails,
+	}
+	for name, n := range raw.Inputs {
+		v, err := nodeValue(&n)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		c.Inputs[name] = v
+	}
+	for name, n := range raw.Expected {
+		v, err := nodeValue(&n)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		c.Expected[name] = v
+	}
+	return nil
+}
+
+func nodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return Value(node.Value), nil
+	case yaml.SequenceNode:
+		values := make([]interface{}, len(node.Content))
+		for i, n := range node.Content {
+			v, err := nodeValue(n)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%w: line %d", ErrUnsupportedValue, node.Line)
+	}
+}
+
+// Suite is a Circuit along with its Cases
+// as found in JSON or YAML vector files
+type Suite struct {
+	Circuit `yaml:",inline"`
+	Cases   []Case `json:"cases" yaml:"cases"`
+}
+
+// Value is a template parameter, either a number or a string
+type Value string
+
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*v = Value(n.String())
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*v = Value(s)
+	return nil
+}
+
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("%w: %s", ErrUnsupportedValue, node.Value)
+	}
+	*v = Value(node.Value)
+	return nil
+}
+
+// Params is a convenience to build Circuit.Params
+func Params(params ...interface{}) []Value {
+	values := make([]Value, len(params))
+	for i, p := range params {
+		values[i] = Value(fmt.Sprint(p))
+	}
+	return values
+}
+
+// Main returns the main component instantiating the template
+func (c Circuit) Main() Program {
+	params := make([]string, len(c.Params))
+	for i, p := range c.Params {
+		params[i] = string(p)
+	}
+	public := ""
+	if len(c.Public) > 0 {
+		public = fmt.Sprintf(" {public[%s]}", strings.Join(c.Public, ", "))
+	}
+	return Program{
+		Identity: "main",
+		Src:      fmt.Sprintf("component main%s = %s(%s);", public, c.Template, strings.Join(params, ", ")),
+	}
+}
+
+// Compile compiles the circuit and fails
+// the test on any compiler error
+// Warnings are tolerated
+func (c Circuit) Compile(t *testing.T) CircuitLibrary {
+	lib := NewEmptyLibrary()
+	reports, err := lib.Compile(append([]CircuitPkg{{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs:      []Program{c.Main()},
+	}}, c.Pkgs...)...)
+	require.Nil(t, err)
+	require.False(t, HasErrors(reports), reports.String())
+	return lib
+}
+
+// HasErrors reports whether the compiler reported an error,
+// veritas reports severities as "Error" & "Warning"
+func HasErrors(reports ReportCollection) bool {
+	for _, report := range reports {
+		if strings.EqualFold(report.Severity, "error") {
+			return true
+		}
+	}
+	return false
+}
+
+// Run compiles the circuit once and runs
+// every case as a subtest
+func Run(t *testing.T, c Circuit, cases ...Case) {
+	lib := c.Compile(t)
+	defer lib.Burn()
+
+	for i, tc := range cases {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("case_%d", i)
+		}
+		t.Run(name, func(t *testing.T) {
+			Check(t, lib, tc)
+		})
+	}
+}
+
+// RunFile loads a Suite from a JSON or YAML file
+// and runs it against pkgs
+func RunFile(t *testing.T, path string, pkgs ...CircuitPkg) {
+	suite, err := LoadSuite(path)
+	require.Nil(t, err)
+	suite.Pkgs = append(suite.Pkgs, pkgs...)
+	Run(t, suite.Circuit, suite.Cases...)
+}
+
+// Check evaluates a single case against a compiled circuit
+func Check(t *testing.T, lib CircuitLibrary, tc Case) {
+	inputs, err := EncodeInputs(tc.Inputs)
+	require.Nil(t, err)
+
+	evaluation, err := lib.Evaluate(inputs)
+	require.Nil(t, err)
+
+	if tc.Fails {
+		require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), "expected unsatisfied constraints")
+		return
+	}
+	require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+	expected, err := expectedSignals(tc.Expected)
+	require.Nil(t, err)
+
+	values := Signals(evaluation)
+	for _, name := range sortedKeys(expected) {
+		actual, ok := values[name]
+		require.True(t, ok, "signal %s not found", name)
+		require.Equal(t, expected[name].String(), actual.String(), name)
+	}
+}
+
+// Signals maps every constrained symbol of an evaluation
+// i.e. main.out[0] to its witness value
+func Signals(evaluation Evaluation) map[string]*big.Int {
+	var (
+		syms      = evaluation.ConstrainedSyms()
+		witnesses = evaluation.WitnessAssignment()
+		values    = make(map[string]*big.Int, len(syms))
+	)
+	for i, sym := range syms {
+		// ConstrainedSyms skips the constant "one" witness
+		values[sym] = witnesses[i+1]
+	}
+	return values
+}
+
+// Signal returns the witness value of a constrained symbol
+// i.e. main.out[0] and fails the test if there is none
+func Signal(t *testing.T, evaluation Evaluation, symbol string) *big.Int {
+	var (
+		syms      = evaluation.ConstrainedSyms()
+		witnesses = evaluation.WitnessAssignment()
+	)
+	for i, sym := range syms {
+		if sym == symbol {
+			// ConstrainedSyms skips the constant "one" witness
+			return witnesses[i+1]
+		}
+	}
+	require.FailNow(t, "symbol not found", symbol)
+	return nil
+}
+
+// EncodeInputs encodes inputs as the input JSON expected by veritas
+// (nested arrays are flattened, elements are decimal strings)
+func EncodeInputs(inputs map[string]interface{}) ([]byte, error) {
+	encoded := make(map[string]interface{}, len(inputs))
+	for name, v := range inputs {
+		values, err := flatten(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if _, isArray := indexable(v); !isArray {
+			encoded[name] = values[0].String()
+			continue
+		}
+		elements := make([]string, len(values))
+		for i, x := range values {
+			elements[i] = x.String()
+		}
+		encoded[name] = elements
+	}
+	return json.Marshal(encoded)
+}
+
+// LoadSuite reads a Suite from a .json, .yaml or .yml file
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var suite Suite
+	switch filepath.Ext(path) {
+	case ".json":
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.UseNumber()
+		err = dec.Decode(&suite)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &suite)
+	default:
+		err = fmt.Errorf("unsupported vector file %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &suite, nil
+}
+
+// expectedSignals maps fully qualified symbols
+// i.e. main.out[0][1] to their expected value
+func expectedSignals(expected map[string]interface{}) (map[string]*big.Int, error) {
+	out := make(map[string]*big.Int)
+	var visit func(name string, v interface{}) error
+	visit = func(name string, v interface{}) error {
+		if rv, ok := indexable(v); ok {
+			for i := 0; i < rv.Len(); i++ {
+				if err := visit(fmt.Sprintf("%s[%d]", name, i), rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		x, err := element(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = x
+		return nil
+	}
+	for name, v := range expected {
+		if !strings.Contains(name, ".") {
+			name = "main." + name
+		}
+		if err := visit(name, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// flatten returns the elements of a (nested) array in row-major order
+func flatten(v interface{}) ([]*big.Int, error) {
+	if rv, ok := indexable(v); ok {
+		var out []*big.Int
+		for i := 0; i < rv.Len(); i++ {
+			inner, err := flatten(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, inner...)
+		}
+		return out, nil
+	}
+	x, err := element(v)
+	if err != nil {
+		return nil, err
+	}
+	return []*big.Int{x}, nil
+}
+
+func indexable(v interface{}) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		return rv, true
+	}
+	return rv, false
+}
+
+// element converts a single signal value to a field element
+func element(v interface{}) (*big.Int, error) {
+	var s string
+	switch e := v.(type) {
+	case *big.Int:
+		return field.Reduce(e), nil
+	case string:
+		s = e
+	case Value:
+		s = string(e)
+	case json.Number:
+		s = e.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+		s = fmt.Sprint(e)
+		if s == "true" {
+			s = "1"
+		} else if s == "false" {
+			s = "0"
+		}
+	case float64:
+		if e != float64(int64(e)) {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedValue, e)
+		}
+		s = fmt.Sprint(int64(e))
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedValue, v)
+	}
+
+	// negative values are reduced into the field
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	x, err := field.FromString(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedValue, err)
+	}
+	if negative {
+		x.Neg(x)
+	}
+	return field.Reduce(x), nil
+}
+
+func sortedKeys(m map[string]*big.Int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package harness
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/bit"
+	"github.com/0xBow-io/privacy-pool-veritas/common/comparators"
+	"github.com/0xBow-io/privacy-pool-veritas/common/utils"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+var bitPkgs = []CircuitPkg{
+	bit.BitifyCircuitPkg,
+	bit.BinSumCircuitPkg,
+	comparators.ComparatorsCircuitPkg,
+	utils.CircuitUtilsPkg,
+}
+
+func Test_Run(t *testing.T) {
+	Run(t, Circuit{Template: "LessThan", Params: Params(8), Pkgs: bitPkgs},
+		Case{Name: "less", Inputs: map[string]interface{}{"in": []int{3, 200}}, Expected: map[string]interface{}{"out": 1}},
+		Case{Name: "equal", Inputs: map[string]interface{}{"in": []string{"200", "0xc8"}}, Expected: map[string]interface{}{"out": 0}},
+		Case{Name: "greater", Inputs: map[string]interface{}{"in": []*big.Int{big.NewInt(201), big.NewInt(200)}}, Expected: map[string]interface{}{"out": false}},
+	)
+}
+
+func Test_RunFile(t *testing.T) {
+	RunFile(t, "testdata/num2bits.json", bitPkgs...)
+}
+
+func Test_EncodeInputs(t *testing.T) {
+	data, err := EncodeInputs(map[string]interface{}{
+		"a": 1,
+		"b": [][]int64{{1, 2}, {3, 4}},
+		"c": -1,
+	})
+	require.Nil(t, err)
+	require.JSONEq(t, `{
+		"a": "1",
+		"b": ["1", "2", "3", "4"],
+		"c": "21888242871839275222246405745257275088548364400416034343698204186575808495616"
+	}`, string(data))
+
+	_, err = EncodeInputs(map[string]interface{}{"a": "abc"})
+	require.NotNil(t, err)
+
+	// strings are either decimal or 0x-prefixed hex
+	data, err = EncodeInputs(map[string]interface{}{"a": "010", "b": "-0x1"})
+	require.Nil(t, err)
+	require.JSONEq(t, `{
+		"a": "10",
+		"b": "21888242871839275222246405745257275088548364400416034343698204186575808495616"
+	}`, string(data))
+	for _, v := range []string{"0b1", "0o7", "1_000"} {
+		_, err = EncodeInputs(map[string]interface{}{"a": v})
+		require.True(t, errors.Is(err, ErrUnsupportedValue), v)
+	}
+
+	expected, err := expectedSignals(map[string]interface{}{
+		"out":           [][]int{{1, 2}, {3, 4}},
+		"main.c.out[1]": "5",
+	})
+	require.Nil(t, err)
+	require.Len(t, expected, 5)
+	require.Equal(t, big.NewInt(3), expected["main.out[1][0]"])
+	require.Equal(t, big.NewInt(5), expected["main.c.out[1]"])
+}
+
+func Test_LoadSuite(t *testing.T) {
+	suite, err := LoadSuite("../common/poseidon/testdata/poseidon_std.yaml")
+	require.Nil(t, err)
+	require.Equal(t, "POSEIDON_STD", suite.Template)
+	require.Equal(t, "component main = POSEIDON_STD(2);", suite.Main().Src)
+	require.Len(t, suite.Cases, 2)
+
+	// large numbers keep their precision
+	expected, err := expectedSignals(suite.Cases[0].Expected)
+	require.Nil(t, err)
+	require.Equal(t, "7853200120776062878684798364095072458815029376092732009249414926327459813530", expected["main.hash"].String())
+
+	_, err = LoadSuite("testdata/missing.toml")
+	require.NotNil(t, err)
+}
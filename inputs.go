@@ -0,0 +1,666 @@
+package privacypool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+)
+
+var (
+	ErrInvalidInputs       = errors.New("invalid PrivacyPool inputs")
+	ErrDuplicateCommitment = errors.New("existing commitment spent more than once")
+)
+
+// PrivacyPoolParams are the template parameters of
+// PrivacyPool(maxTreeDepth, cipherLen, tupleLen, nExisting, nNew)
+type PrivacyPoolParams struct {
+	MaxTreeDepth int
+	CipherLen    int
+	TupleLen     int
+	NExisting    int
+	NNew         int
+}
+
+// Instance returns the template instantiation i.e. PrivacyPool(32, 7, 4, 2, 2)
+func (p PrivacyPoolParams) Instance() string {
+	return fmt.Sprintf("PrivacyPool(%d, %d, %d, %d, %d)",
+		p.MaxTreeDepth, p.CipherLen, p.TupleLen, p.NExisting, p.NNew)
+}
+
+// PrivacyPoolInputs holds every input signal of PrivacyPool
+// and marshals to the input JSON expected by the template
+type PrivacyPoolInputs struct {
+	/// **** Public Signals ****
+	Scope             *big.Int
+	ActualTreeDepth   *big.Int
+	Context           *big.Int
+	ExternIO          [2]*big.Int
+	ExistingStateRoot *big.Int
+	NewSaltPublicKey  [][2]*big.Int
+	NewCiphertext     [][]*big.Int
+
+	/// **** Private Signals ****
+	PrivateKey      []*big.Int
+	Nonce           []*big.Int
+	ExSaltPublicKey [][2]*big.Int
+	ExCiphertext    [][]*big.Int
+	ExIndex         []*big.Int
+	ExSiblings      [][]*big.Int
+}
+
+// Validate checks the dimension of every signal against params,
+// that every element is a canonical field element
+// & that every saltPublicKey is in the prime subgroup
+func (in *PrivacyPoolInputs) Validate(params PrivacyPoolParams) error {
+	var (
+		nTotal = params.NExisting + params.NNew
+		errs   []error
+		check  = func(name string, got, expected int) bool {
+			if got != expected {
+				errs = append(errs, fmt.Errorf("%s: expected %d elements, got %d", name, expected, got))
+				return false
+			}
+			return true
+		}
+		elements = func(name string, values ...*big.Int) {
+			for i, v := range values {
+				if !field.IsCanonical(v) {
+					errs = append(errs, fmt.Errorf("%s[%d]: not a field element", name, i))
+				}
+			}
+		}
+		salts = func(name string, keys [][2]*big.Int) {
+			for i, pk := range keys {
+				name := fmt.Sprintf("%s[%d]", name, i)
+				elements(name, pk[:]...)
+				if !(&babyjub.Point{X: pk[0], Y: pk[1]}).InSubGroup() {
+					errs = append(errs, fmt.Errorf("%s: %w", name, babyjub.ErrPointNotInSubGroup))
+				}
+			}
+		}
+	)
+
+	if params.TupleLen < core.TupleLen {
+		errs = append(errs, fmt.Errorf("tupleLen: expected at least %d, got %d", core.TupleLen, params.TupleLen))
+	} else if expected := core.CiphertextLength(params.TupleLen); params.CipherLen != expected {
+		errs = append(errs, fmt.Errorf("cipherLen: expected %d for tupleLen %d, got %d", expected, params.TupleLen, params.CipherLen))
+	}
+
+	elements("scope", in.Scope)
+	elements("actualTreeDepth", in.ActualTreeDepth)
+	if in.ActualTreeDepth != nil && (in.ActualTreeDepth.Sign() < 0 || in.ActualTreeDepth.Cmp(big.NewInt(int64(params.MaxTreeDepth))) > 0) {
+		errs = append(errs, fmt.Errorf("actualTreeDepth: expected at most %d, got %v", params.MaxTreeDepth, in.ActualTreeDepth))
+	}
+	elements("context", in.Context)
+	elements("externIO", in.ExternIO[:]...)
+	elements("existingStateRoot", in.ExistingStateRoot)
+
+	if check("newSaltPublicKey", len(in.NewSaltPublicKey), params.NNew) {
+		salts("newSaltPublicKey", in.NewSaltPublicKey)
+	}
+	if check("newCiphertext", len(in.NewCiphertext), params.NNew) {
+		for i, c := range in.NewCiphertext {
+			if name := fmt.Sprintf("newCiphertext[%d]", i); check(name, len(c), params.CipherLen) {
+				elements(name, c...)
+			}
+		}
+	}
+	if check("privateKey", len(in.PrivateKey), nTotal) {
+		elements("privateKey", in.PrivateKey...)
+	}
+	if check("nonce", len(in.Nonce), nTotal) {
+		elements("nonce", in.Nonce...)
+	}
+	if check("exSaltPublicKey", len(in.ExSaltPublicKey), params.NExisting) {
+		salts("exSaltPublicKey", in.ExSaltPublicKey)
+	}
+	if check("exCiphertext", len(in.ExCiphertext), params.NExisting) {
+		for i, c := range in.ExCiphertext {
+			if name := fmt.Sprintf("exCiphertext[%d]", i); check(name, len(c), params.CipherLen) {
+				elements(name, c...)
+			}
+		}
+	}
+	if check("exIndex", len(in.ExIndex), params.NExisting) {
+		elements("exIndex", in.ExIndex...)
+	}
+	if check("exSiblings", len(in.ExSiblings), params.NExisting) {
+		for i, s := range in.ExSiblings {
+			if name := fmt.Sprintf("exSiblings[%d]", i); check(name, len(s), params.MaxTreeDepth) {
+				elements(name, s...)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w for %s: %w", ErrInvalidInputs, params.Instance(), errors.Join(errs...))
+	}
+	return nil
+}
+
+// CheckDuplicates rejects inputs spending the same existing commitment
+// more than once, i.e. non-void existing commitments sharing a nullRoot
+// (see DistinctNullRoots). Commitments that can't be opened
+// are treated as void, the circuit doesn't count their value.
+// A saltPublicKey outside of the prime subgroup is rejected
+// as by RecoverCommitmentKeys, a torsion shifted saltPublicKey
+// opens the same commitment under another nullRoot.
+// The inputs are expected to be valid (see Validate)
+func (in *PrivacyPoolInputs) CheckDuplicates(params PrivacyPoolParams) error {
+	seen := make(map[string]int)
+	for i, ciphertext := range in.ExCiphertext {
+		salt := &babyjub.Point{X: in.ExSaltPublicKey[i][0], Y: in.ExSaltPublicKey[i][1]}
+		if !salt.InSubGroup() {
+			return fmt.Errorf("%w: exSaltPublicKey[%d]: %w", ErrInvalidInputs, i, babyjub.ErrPointNotInSubGroup)
+		}
+		c, err := core.OpenCommitment(in.PrivateKey[i], salt, in.Nonce[i], ciphertext, params.TupleLen)
+		if err != nil || c.Value.Sign() == 0 {
+			continue
+		}
+		nullRoot := c.NullRoot.String()
+		if j, ok := seen[nullRoot]; ok {
+			return fmt.Errorf("%w: existing commitments %d & %d share nullRoot %s", ErrDuplicateCommitment, j, i, nullRoot)
+		}
+		seen[nullRoot] = i
+	}
+	return nil
+}
+
+// MarshalJSON encodes the inputs as the input JSON of PrivacyPool
+// Field elements are encoded as decimal strings and multi-dimensional
+// signals are flattened in row-major order, i.e. exSiblings[nExisting][maxTreeDepth]
+// is encoded as a single array of nExisting*maxTreeDepth elements
+func (in PrivacyPoolInputs) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Scope             []string `json:"scope"`
+		ActualTreeDepth   []string `json:"actualTreeDepth"`
+		Context           []string `json:"context"`
+		ExternIO          []string `json:"externIO"`
+		ExistingStateRoot []string `json:"existingStateRoot"`
+		NewSaltPublicKey  []string `json:"newSaltPublicKey"`
+		NewCiphertext     []string `json:"newCiphertext"`
+		PrivateKey        []string `json:"privateKey"`
+		Nonce             []string `json:"nonce"`
+		ExSaltPublicKey   []string `json:"exSaltPublicKey"`
+		ExCiphertext      []string `json:"exCiphertext"`
+		ExIndex           []string `json:"exIndex"`
+		ExSiblings        []string `json:"exSiblings"`
+	}{
+		Scope:             toDecimals(in.Scope),
+		ActualTreeDepth:   toDecimals(in.ActualTreeDepth),
+		Context:           toDecimals(in.Context),
+		ExternIO:          toDecimals(in.ExternIO[:]...),
+		ExistingStateRoot: toDecimals(in.ExistingStateRoot),
+		NewSaltPublicKey:  toDecimals(flattenPairs(in.NewSaltPublicKey)...),
+		NewCiphertext:     toDecimals(flattenMatrix(in.NewCiphertext)...),
+		PrivateKey:        toDecimals(in.PrivateKey...),
+		Nonce:             toDecimals(in.Nonce...),
+		ExSaltPublicKey:   toDecimals(flattenPairs(in.ExSaltPublicKey)...),
+		ExCiphertext:      toDecimals(flattenMatrix(in.ExCiphertext)...),
+		ExIndex:           toDecimals(in.ExIndex...),
+		ExSiblings:        toDecimals(flattenMatrix(in.ExSiblings)...),
+	})
+}
+
+// ParsePrivacyPoolInputs parses the input JSON of PrivacyPool
+// and validates it against params.
+// Signals can either be nested or flattened arrays,
+// field elements either numbers, decimal or 0x-prefixed hex strings.
+func ParsePrivacyPoolInputs(data []byte, params PrivacyPoolParams) (*PrivacyPoolInputs, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInputs, err)
+	}
+
+	var (
+		in     PrivacyPoolInputs
+		nTotal = params.NExisting + params.NNew
+		errs   []error
+		read   = func(name string, size int) []*big.Int {
+			msg, ok := raw[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: missing", name))
+				return nil
+			}
+			delete(raw, name)
+			values, err := parseElements(msg)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				return nil
+			}
+			if len(values) != size {
+				errs = append(errs, fmt.Errorf("%s: expected %d elements, got %d", name, size, len(values)))
+				return nil
+			}
+			return values
+		}
+		one = func(name string) *big.Int {
+			if values := read(name, 1); values != nil {
+				return values[0]
+			}
+			return nil
+		}
+	)
+
+	in.Scope = one("scope")
+	in.ActualTreeDepth = one("actualTreeDepth")
+	in.Context = one("context")
+	if externIO := read("externIO", 2); externIO != nil {
+		in.ExternIO = [2]*big.Int{externIO[0], externIO[1]}
+	}
+	in.ExistingStateRoot = one("existingStateRoot")
+	in.NewSaltPublicKey = toPairs(read("newSaltPublicKey", params.NNew*2))
+	in.NewCiphertext = toMatrix(read("newCiphertext", params.NNew*params.CipherLen), params.CipherLen)
+	in.PrivateKey = read("privateKey", nTotal)
+	in.Nonce = read("nonce", nTotal)
+	in.ExSaltPublicKey = toPairs(read("exSaltPublicKey", params.NExisting*2))
+	in.ExCiphertext = toMatrix(read("exCiphertext", params.NExisting*params.CipherLen), params.CipherLen)
+	in.ExIndex = read("exIndex", params.NExisting)
+	in.ExSiblings = toMatrix(read("exSiblings", params.NExisting*params.MaxTreeDepth), params.MaxTreeDepth)
+
+	for name := range raw {
+		errs = append(errs, fmt.Errorf("%s: unknown signal", name))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w for %s: %w", ErrInvalidInputs, params.Instance(), errors.Join(errs...))
+	}
+	if err := in.Validate(params); err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// PrivacyPoolInputsBuilder assembles PrivacyPoolInputs
+// one commitment at a time
+type PrivacyPoolInputsBuilder struct {
+	params   PrivacyPoolParams
+	inputs   PrivacyPoolInputs
+	exKeys   []*big.Int
+	exNonce  []*big.Int
+	exDepths []int
+	newKeys  []*big.Int
+	newNonce []*big.Int
+	// externAsset is only an input of PrivacyPoolWithAssets
+	externAsset *big.Int
+	errs        []error
+}
+
+func NewPrivacyPoolInputsBuilder(params PrivacyPoolParams) *PrivacyPoolInputsBuilder {
+	return &PrivacyPoolInputsBuilder{
+		params: params,
+		inputs: PrivacyPoolInputs{
+			Context:  big.NewInt(0),
+			ExternIO: [2]*big.Int{big.NewInt(0), big.NewInt(0)},
+		},
+	}
+}
+
+func (b *PrivacyPoolInputsBuilder) Scope(scope *big.Int) *PrivacyPoolInputsBuilder {
+	b.inputs.Scope = scope
+	return b
+}
+
+func (b *PrivacyPoolInputsBuilder) Context(context *big.Int) *PrivacyPoolInputsBuilder {
+	b.inputs.Context = context
+	return b
+}
+
+// ExternIO sets the external input (deposit) & output (withdrawal) values
+func (b *PrivacyPoolInputsBuilder) ExternIO(input, output *big.Int) *PrivacyPoolInputsBuilder {
+	b.inputs.ExternIO = [2]*big.Int{input, output}
+	return b
+}
+
+// ExternAsset sets the asset identifier externIO is denominated in
+// (see BuildWithAssets)
+func (b *PrivacyPoolInputsBuilder) ExternAsset(assetId *big.Int) *PrivacyPoolInputsBuilder {
+	b.externAsset = assetId
+	return b
+}
+
+// StateTree sets the existingStateRoot & actualTreeDepth
+func (b *PrivacyPoolInputsBuilder) StateTree(root *big.Int, actualDepth int) *PrivacyPoolInputsBuilder {
+	b.inputs.ExistingStateRoot = root
+	b.inputs.ActualTreeDepth = big.NewInt(int64(actualDepth))
+	return b
+}
+
+// AddExisting appends the private signals of an existing commitment
+func (b *PrivacyPoolInputsBuilder) AddExisting(
+	privateKey, nonce *big.Int,
+	saltPublicKey [2]*big.Int,
+	ciphertext []*big.Int,
+	index *big.Int,
+	siblings []*big.Int,
+) *PrivacyPoolInputsBuilder {
+	b.exKeys = append(b.exKeys, privateKey)
+	b.exNonce = append(b.exNonce, nonce)
+	b.exDepths = append(b.exDepths, -1)
+	b.inputs.ExSaltPublicKey = append(b.inputs.ExSaltPublicKey, saltPublicKey)
+	b.inputs.ExCiphertext = append(b.inputs.ExCiphertext, ciphertext)
+	b.inputs.ExIndex = append(b.inputs.ExIndex, index)
+	b.inputs.ExSiblings = append(b.inputs.ExSiblings, siblings)
+	return b
+}
+
+// AddExistingCommitment appends an existing commitment
+// with its membership proof in the state tree
+func (b *PrivacyPoolInputsBuilder) AddExistingCommitment(c *core.Commitment, proof *merkletree.LeanIMTProof) *PrivacyPoolInputsBuilder {
+	if c == nil || proof == nil {
+		b.errs = append(b.errs, errors.New("existing commitment & proof are required"))
+		return b
+	}
+	if proof.Leaf.Cmp(c.CommitmentRoot) != 0 {
+		b.errs = append(b.errs, fmt.Errorf("existing commitment %d: proof leaf is not the commitmentRoot", len(b.exKeys)))
+	}
+	if err := core.CheckValue(c.Value); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("existing commitment %d: %w", len(b.exKeys), err))
+	}
+	b.AddExisting(
+		c.PrivateKey, c.Nonce,
+		[2]*big.Int{c.SaltPublicKey.X, c.SaltPublicKey.Y},
+		c.Ciphertext,
+		big.NewInt(int64(proof.LeafIndex)),
+		proof.Siblings,
+	)
+	// all membership proofs share the same actualTreeDepth
+	b.exDepths[len(b.exDepths)-1] = proof.ActualDepth
+	return b
+}
+
+// AddNew appends the signals of a new commitment
+func (b *PrivacyPoolInputsBuilder) AddNew(
+	privateKey, nonce *big.Int,
+	saltPublicKey [2]*big.Int,
+	ciphertext []*big.Int,
+) *PrivacyPoolInputsBuilder {
+	b.newKeys = append(b.newKeys, privateKey)
+	b.newNonce = append(b.newNonce, nonce)
+	b.inputs.NewSaltPublicKey = append(b.inputs.NewSaltPublicKey, saltPublicKey)
+	b.inputs.NewCiphertext = append(b.inputs.NewCiphertext, ciphertext)
+	return b
+}
+
+// AddNewCommitment appends a new commitment
+func (b *PrivacyPoolInputsBuilder) AddNewCommitment(c *core.Commitment) *PrivacyPoolInputsBuilder {
+	if c == nil {
+		b.errs = append(b.errs, errors.New("new commitment is required"))
+		return b
+	}
+	if err := core.CheckValue(c.Value); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("new commitment %d: %w", len(b.newKeys), err))
+	}
+	return b.AddNew(
+		c.PrivateKey, c.Nonce,
+		[2]*big.Int{c.SaltPublicKey.X, c.SaltPublicKey.Y},
+		c.Ciphertext,
+	)
+}
+
+// Build validates & returns the assembled inputs
+// privateKey & nonce are ordered as existing commitments then new commitments
+func (b *PrivacyPoolInputsBuilder) Build() (*PrivacyPoolInputs, error) {
+	errs := append([]error{}, b.errs...)
+	for i, depth := range b.exDepths {
+		if depth >= 0 && (b.inputs.ActualTreeDepth == nil || b.inputs.ActualTreeDepth.Cmp(big.NewInt(int64(depth))) != 0) {
+			errs = append(errs, fmt.Errorf("existing commitment %d: proof depth %d does not match actualTreeDepth %v",
+				i, depth, b.inputs.ActualTreeDepth))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInputs, errors.Join(errs...))
+	}
+	in := b.inputs
+	in.PrivateKey = append(append([]*big.Int{}, b.exKeys...), b.newKeys...)
+	in.Nonce = append(append([]*big.Int{}, b.exNonce...), b.newNonce...)
+	if err := in.Validate(b.params); err != nil {
+		return nil, err
+	}
+	if err := in.CheckDuplicates(b.params); err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// BuildWithRelayer is Build for PrivacyPoolWithRelayer,
+// fee is paid to relayer on top of the external output
+func (b *PrivacyPoolInputsBuilder) BuildWithRelayer(relayer, fee *big.Int) (*PrivacyPoolRelayerInputs, error) {
+	inputs, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	in := &PrivacyPoolRelayerInputs{PrivacyPoolInputs: *inputs, Fee: fee, Relayer: relayer}
+	if err := in.Validate(b.params); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// PrivacyPoolRelayerInputs holds every input signal of
+// PrivacyPoolWithRelayer, i.e. PrivacyPoolInputs with the relayer fee
+type PrivacyPoolRelayerInputs struct {
+	PrivacyPoolInputs
+	Fee     *big.Int
+	Relayer *big.Int
+}
+
+// Validate checks the PrivacyPoolInputs against params,
+// that relayer is a field element and that fee fits within 252 bits
+// (as range checked by the circuit)
+func (in *PrivacyPoolRelayerInputs) Validate(params PrivacyPoolParams) error {
+	var errs []error
+	if err := in.PrivacyPoolInputs.Validate(params); err != nil {
+		errs = append(errs, err)
+	}
+	if !field.IsCanonical(in.Relayer) {
+		errs = append(errs, errors.New("relayer: not a field element"))
+	}
+	if in.Fee == nil || in.Fee.Sign() < 0 || in.Fee.BitLen() > 252 {
+		errs = append(errs, errors.New("fee: not a 252 bits value"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidInputs, errors.Join(errs...))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the inputs as the input JSON of PrivacyPoolWithRelayer
+// (see PrivacyPoolInputs.MarshalJSON)
+func (in PrivacyPoolRelayerInputs) MarshalJSON() ([]byte, error) {
+	data, err := in.PrivacyPoolInputs.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var signals map[string]json.RawMessage
+	if err := json.Unmarshal(data, &signals); err != nil {
+		return nil, err
+	}
+	for name, v := range map[string]*big.Int{"fee": in.Fee, "relayer": in.Relayer} {
+		if signals[name], err = json.Marshal(toDecimals(v)); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(signals)
+}
+
+// BuildWithAssets is Build for PrivacyPoolWithAssets,
+// the commitments being asset tagged (see core.NewAssetCommitment)
+func (b *PrivacyPoolInputsBuilder) BuildWithAssets() (*PrivacyPoolAssetInputs, error) {
+	if b.params.TupleLen < core.AssetTupleLen {
+		return nil, fmt.Errorf("%w: tupleLen: expected at least %d for asset tagged commitments, got %d",
+			ErrInvalidInputs, core.AssetTupleLen, b.params.TupleLen)
+	}
+	if b.externAsset == nil {
+		return nil, fmt.Errorf("%w: externAsset is required", ErrInvalidInputs)
+	}
+	inputs, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	in := &PrivacyPoolAssetInputs{PrivacyPoolInputs: *inputs, ExternAsset: b.externAsset}
+	if err := in.Validate(b.params); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// PrivacyPoolAssetInputs holds every input signal of PrivacyPoolWithAssets,
+// i.e. PrivacyPoolInputs with the asset of externIO
+type PrivacyPoolAssetInputs struct {
+	PrivacyPoolInputs
+	ExternAsset *big.Int
+}
+
+// Validate checks the PrivacyPoolInputs against params
+// and that externAsset is a field element
+func (in *PrivacyPoolAssetInputs) Validate(params PrivacyPoolParams) error {
+	var errs []error
+	if err := in.PrivacyPoolInputs.Validate(params); err != nil {
+		errs = append(errs, err)
+	}
+	if !field.IsCanonical(in.ExternAsset) {
+		errs = append(errs, errors.New("externAsset: not a field element"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidInputs, errors.Join(errs...))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the inputs as the input JSON of PrivacyPoolWithAssets
+// (see PrivacyPoolInputs.MarshalJSON)
+func (in PrivacyPoolAssetInputs) MarshalJSON() ([]byte, error) {
+	data, err := in.PrivacyPoolInputs.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var signals map[string]json.RawMessage
+	if err := json.Unmarshal(data, &signals); err != nil {
+		return nil, err
+	}
+	if signals["externAsset"], err = json.Marshal(toDecimals(in.ExternAsset)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(signals)
+}
+
+// marshalSignals encodes in as PrivacyPoolInputs.MarshalJSON does
+// without the dropped signals & with the extra signals
+func marshalSignals(in PrivacyPoolInputs, drop []string, extra map[string][]string) ([]byte, error) {
+	data, err := in.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var signals map[string]json.RawMessage
+	if err := json.Unmarshal(data, &signals); err != nil {
+		return nil, err
+	}
+	for _, name := range drop {
+		delete(signals, name)
+	}
+	for name, values := range extra {
+		if signals[name], err = json.Marshal(values); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(signals)
+}
+
+func toDecimals(values ...*big.Int) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			out[i] = v.String()
+		}
+	}
+	return out
+}
+
+func flattenPairs(values [][2]*big.Int) []*big.Int {
+	out := make([]*big.Int, 0, len(values)*2)
+	for _, v := range values {
+		out = append(out, v[0], v[1])
+	}
+	return out
+}
+
+func flattenMatrix(values [][]*big.Int) []*big.Int {
+	out := make([]*big.Int, 0)
+	for _, v := range values {
+		out = append(out, v...)
+	}
+	return out
+}
+
+func toPairs(flat []*big.Int) [][2]*big.Int {
+	if flat == nil {
+		return nil
+	}
+	out := make([][2]*big.Int, len(flat)/2)
+	for i := range out {
+		out[i] = [2]*big.Int{flat[i*2], flat[i*2+1]}
+	}
+	return out
+}
+
+func toMatrix(flat []*big.Int, cols int) [][]*big.Int {
+	if flat == nil {
+		return nil
+	}
+	out := make([][]*big.Int, 0)
+	for i := 0; cols > 0 && i < len(flat); i += cols {
+		out = append(out, flat[i:i+cols])
+	}
+	return out
+}
+
+// parseElements flattens a (nested) JSON array
+// or a single JSON value into field elements
+func parseElements(msg json.RawMessage) ([]*big.Int, error) {
+	var (
+		value interface{}
+		out   []*big.Int
+		dec   = json.NewDecoder(bytes.NewReader(msg))
+		visit func(v interface{}) error
+	)
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	visit = func(v interface{}) error {
+		switch e := v.(type) {
+		case []interface{}:
+			for _, inner := range e {
+				if err := visit(inner); err != nil {
+					return err
+				}
+			}
+		case string:
+			x, err := field.FromString(e)
+			if err != nil {
+				return err
+			}
+			out = append(out, x)
+		case json.Number:
+			x, err := field.FromString(e.String())
+			if err != nil {
+				return err
+			}
+			out = append(out, x)
+		default:
+			return fmt.Errorf("unexpected value %v", v)
+		}
+		return nil
+	}
+	if err := visit(value); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
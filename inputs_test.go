@@ -0,0 +1,473 @@
+package privacypool
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+var testParams = PrivacyPoolParams{
+	MaxTreeDepth: 4,
+	CipherLen:    7,
+	TupleLen:     4,
+	NExisting:    2,
+	NNew:         2,
+}
+
+// compilePrivacyPool compiles main against all circuit pkgs
+func compilePrivacyPool(t *testing.T, main string) CircuitLibrary {
+	pkgs, err := Bundle()
+	require.Nil(t, err)
+
+	lib := NewEmptyLibrary()
+	reports, err := lib.Compile(append(pkgs, CircuitPkg{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs:      []Program{{Identity: "main", Src: main}},
+	})...)
+	require.Nil(t, err)
+	require.False(t, harness.HasErrors(reports), reports.String())
+	return lib
+}
+
+func randomBelow(t *testing.T, max *big.Int) *big.Int {
+	v, err := rand.Int(rand.Reader, max)
+	require.Nil(t, err)
+	return v
+}
+
+func newTestCommitment(t *testing.T, scope *big.Int, value int64) *core.Commitment {
+	c, err := core.NewCommitment(
+		scope,
+		big.NewInt(value),
+		randomBelow(t, babyjub.SubOrder),
+		randomBelow(t, babyjub.SubOrder),
+		randomBelow(t, new(big.Int).Lsh(big.NewInt(1), 128)),
+	)
+	require.Nil(t, err)
+	return c
+}
+
+// newTestStateTree inserts the commitment roots of existing
+// into a state tree padded with random leaves to 4 leaves
+func newTestStateTree(t *testing.T, existing ...*core.Commitment) *merkletree.LeanIMT {
+	tree := merkletree.NewLeanIMT()
+	for _, c := range existing {
+		require.Nil(t, tree.Insert(c.CommitmentRoot))
+	}
+	for tree.Size() < 4 {
+		require.Nil(t, tree.Insert(randomBelow(t, field.Modulus)))
+	}
+	return tree
+}
+
+// newTestInputs spends existing commitments of exValues
+// into new commitments of newValues
+func newTestInputs(t *testing.T, params PrivacyPoolParams, externIO [2]int64, exValues, newValues []int64) (*PrivacyPoolInputsBuilder, []*core.Commitment, []*core.Commitment) {
+	var (
+		scope    = randomBelow(t, field.Modulus)
+		existing = make([]*core.Commitment, len(exValues))
+		created  = make([]*core.Commitment, len(newValues))
+	)
+	for i, v := range exValues {
+		existing[i] = newTestCommitment(t, scope, v)
+	}
+	for i, v := range newValues {
+		created[i] = newTestCommitment(t, scope, v)
+	}
+
+	tree := newTestStateTree(t, existing...)
+	builder := NewPrivacyPoolInputsBuilder(params).
+		Scope(scope).
+		Context(randomBelow(t, field.Modulus)).
+		ExternIO(big.NewInt(externIO[0]), big.NewInt(externIO[1])).
+		StateTree(tree.Root(), tree.Depth())
+
+	for _, c := range existing {
+		proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), params.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(c, proof)
+	}
+	for _, c := range created {
+		builder.AddNewCommitment(c)
+	}
+	return builder, existing, created
+}
+
+func Test_PrivacyPoolInputs_JSON(t *testing.T) {
+	builder, _, _ := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+
+	// every field element is encoded as a decimal string
+	// and multi-dimensional signals are flattened
+	var raw map[string]interface{}
+	require.Nil(t, json.Unmarshal(data, &raw))
+	require.Len(t, raw, 13)
+	require.IsType(t, "", raw["scope"].([]interface{})[0])
+	require.Len(t, raw["exSiblings"], testParams.NExisting*testParams.MaxTreeDepth)
+	require.Len(t, raw["newCiphertext"], testParams.NNew*testParams.CipherLen)
+	require.Len(t, raw["privateKey"], testParams.NExisting+testParams.NNew)
+
+	parsed, err := ParsePrivacyPoolInputs(data, testParams)
+	require.Nil(t, err)
+	require.Equal(t, inputs, parsed)
+
+	// nested arrays, bare numbers & hex strings are accepted
+	raw["scope"] = 1
+	raw["actualTreeDepth"] = "0x2"
+	raw["exSiblings"] = [][]string{
+		toDecimals(inputs.ExSiblings[0]...),
+		toDecimals(inputs.ExSiblings[1]...),
+	}
+	data, err = json.Marshal(raw)
+	require.Nil(t, err)
+	parsed, err = ParsePrivacyPoolInputs(data, testParams)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(1), parsed.Scope)
+	require.Equal(t, big.NewInt(2), parsed.ActualTreeDepth)
+	require.Equal(t, inputs.ExSiblings, parsed.ExSiblings)
+
+	// dimensions are checked against the template parameters
+	_, err = ParsePrivacyPoolInputs(data, PrivacyPoolParams{MaxTreeDepth: 32, CipherLen: 7, TupleLen: 4, NExisting: 2, NNew: 2})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "exSiblings: expected 64 elements, got 8")
+
+	// cipherLen must match tupleLen
+	params := testParams
+	params.TupleLen = 7
+	require.Contains(t, inputs.Validate(params).Error(), "cipherLen: expected 10 for tupleLen 7, got 7")
+	params.CipherLen, params.TupleLen = 10, 7
+	require.Contains(t, inputs.Validate(params).Error(), "newCiphertext[0]: expected 10 elements, got 7")
+
+	// actualTreeDepth can't exceed maxTreeDepth
+	deep := *inputs
+	deep.ActualTreeDepth = big.NewInt(int64(testParams.MaxTreeDepth + 1))
+	err = deep.Validate(testParams)
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	require.Contains(t, err.Error(), fmt.Sprintf("actualTreeDepth: expected at most %d, got %d", testParams.MaxTreeDepth, testParams.MaxTreeDepth+1))
+
+	_, err = ParsePrivacyPoolInputs([]byte(`{"scope": "abc"}`), testParams)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "scope")
+	require.Contains(t, err.Error(), "exSiblings: missing")
+}
+
+func Test_PrivacyPoolInputsBuilder(t *testing.T) {
+	// missing a new commitment
+	builder, _, _ := newTestInputs(t, testParams, [2]int64{0, 0}, []int64{10, 0}, []int64{10})
+	_, err := builder.Build()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "newCiphertext: expected 2 elements, got 1")
+	require.Contains(t, err.Error(), "privateKey: expected 4 elements, got 3")
+
+	// membership proofs must match actualTreeDepth
+	builder, existing, _ := newTestInputs(t, testParams, [2]int64{0, 0}, []int64{10, 0}, []int64{10, 0})
+	tree := newTestStateTree(t, existing...)
+	require.Nil(t, tree.Insert(big.NewInt(1)))
+	proof, err := tree.GenerateProof(tree.Size()-1, testParams.MaxTreeDepth)
+	require.Nil(t, err)
+	_, err = builder.AddExistingCommitment(existing[0], proof).Build()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "proof leaf is not the commitmentRoot")
+	require.Contains(t, err.Error(), "does not match actualTreeDepth")
+}
+
+// Evaluate PrivacyPool with inputs produced by the builder
+func Test_PrivacyPoolInputs_Evaluate(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, newSaltPublicKey, newCiphertext]} = "+testParams.Instance()+";")
+	defer lib.Burn()
+
+	builder, _, created := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+	evaluation, err := lib.Evaluate(data)
+	require.Nil(t, err)
+	require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+	for i, c := range created {
+		k := testParams.NExisting + i
+		require.Equal(t, c.CommitmentRoot, harness.Signal(t, evaluation, "main.newCommitmentRoot["+itoa(k)+"]"))
+		require.Equal(t, c.CommitmentHash, harness.Signal(t, evaluation, "main.newCommitmentHash["+itoa(k)+"]"))
+	}
+
+	// unbalanced
+	builder, _, _ = newTestInputs(t, testParams, [2]int64{0, 31}, []int64{100, 50}, []int64{120, 0})
+	inputs, err = builder.Build()
+	require.Nil(t, err)
+	data, err = json.Marshal(inputs)
+	require.Nil(t, err)
+	evaluation, err = lib.Evaluate(data)
+	require.Nil(t, err)
+	require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()))
+}
+
+func itoa(i int) string { return big.NewInt(int64(i)).String() }
+
+func Test_PrivacyPoolWithRelayer(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, fee, relayer, existingStateRoot, newSaltPublicKey, newCiphertext]} = PrivacyPoolWithRelayer(4, 7, 4, 2, 2);")
+	defer lib.Burn()
+
+	relayer := randomBelow(t, new(big.Int).Lsh(big.NewInt(1), 160))
+	for _, tc := range []struct {
+		name     string
+		fee      int64
+		externIO [2]int64
+		valid    bool
+	}{
+		{"fee", 5, [2]int64{0, 25}, true},
+		{"no fee", 0, [2]int64{0, 30}, true},
+		// the fee must be accounted for
+		{"unbalanced", 5, [2]int64{0, 30}, false},
+	} {
+		// spend 100 + 50 into 120, withdrawing 30
+		builder, _, _ := newTestInputs(t, testParams, tc.externIO, []int64{100, 50}, []int64{120, 0})
+		inputs, err := builder.BuildWithRelayer(relayer, big.NewInt(tc.fee))
+		require.Nil(t, err)
+
+		data, err := json.Marshal(inputs)
+		require.Nil(t, err)
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		if !tc.valid {
+			require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), tc.name)
+			continue
+		}
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0, tc.name)
+		require.Equal(t, relayer, harness.Signal(t, evaluation, "main.relayer"))
+	}
+
+	// the fee is range checked
+	builder, _, _ := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.BuildWithRelayer(relayer, new(big.Int).Lsh(big.NewInt(1), 252))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "fee: not a 252 bits value")
+
+	// a negative fee (wrapped around the field) fails the circuit
+	builder, _, _ = newTestInputs(t, testParams, [2]int64{0, 35}, []int64{100, 50}, []int64{120, 0})
+	inputs, err = builder.BuildWithRelayer(relayer, big.NewInt(0))
+	require.Nil(t, err)
+	inputs.Fee = new(big.Int).Sub(field.Modulus, big.NewInt(5))
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+	evaluation, err := lib.Evaluate(data)
+	require.Nil(t, err)
+	require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()))
+}
+
+// Commitment values are range checked,
+// sums of values can't wrap around the field
+func Test_PrivacyPool_ValueRange(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, newSaltPublicKey, newCiphertext]} = "+testParams.Instance()+";")
+	defer lib.Burn()
+
+	var (
+		scope  = randomBelow(t, field.Modulus)
+		commit = func(value *big.Int) *core.Commitment {
+			c, err := core.NewCommitment(
+				scope,
+				value,
+				randomBelow(t, babyjub.SubOrder),
+				randomBelow(t, babyjub.SubOrder),
+				randomBelow(t, new(big.Int).Lsh(big.NewInt(1), 128)),
+			)
+			require.Nil(t, err)
+			return c
+		}
+		// -50 in the field
+		negative = new(big.Int).Sub(field.Modulus, big.NewInt(50))
+		maxValue = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), core.ValueBits), big.NewInt(1))
+	)
+
+	for _, tc := range []struct {
+		name     string
+		existing []*big.Int
+		created  []*big.Int
+		valid    bool
+	}{
+		{"max value", []*big.Int{maxValue, big.NewInt(0)}, []*big.Int{maxValue, big.NewInt(0)}, true},
+		// 100 + (p - 50) == 50
+		{"wrapping existing", []*big.Int{big.NewInt(100), negative}, []*big.Int{big.NewInt(50), big.NewInt(0)}, false},
+		// 150 + (p - 50) == 100
+		{"wrapping new", []*big.Int{big.NewInt(100), big.NewInt(0)}, []*big.Int{big.NewInt(150), negative}, false},
+		{"out of range", []*big.Int{new(big.Int).Add(maxValue, big.NewInt(1)), big.NewInt(0)}, []*big.Int{new(big.Int).Add(maxValue, big.NewInt(1)), big.NewInt(0)}, false},
+	} {
+		existing := make([]*core.Commitment, len(tc.existing))
+		for i, v := range tc.existing {
+			existing[i] = commit(v)
+		}
+		created := make([]*core.Commitment, len(tc.created))
+		for i, v := range tc.created {
+			created[i] = commit(v)
+		}
+		tree := newTestStateTree(t, existing...)
+		// raw bypasses the builder value checks
+		// so that the circuit gets to reject the values
+		build := func(raw bool) (*PrivacyPoolInputs, error) {
+			builder := NewPrivacyPoolInputsBuilder(testParams).
+				Scope(scope).
+				StateTree(tree.Root(), tree.Depth())
+			for _, c := range existing {
+				proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), testParams.MaxTreeDepth)
+				require.Nil(t, err)
+				if raw {
+					builder.AddExisting(c.PrivateKey, c.Nonce, [2]*big.Int{c.SaltPublicKey.X, c.SaltPublicKey.Y},
+						c.Ciphertext, big.NewInt(int64(proof.LeafIndex)), proof.Siblings)
+				} else {
+					builder.AddExistingCommitment(c, proof)
+				}
+			}
+			for _, c := range created {
+				if raw {
+					builder.AddNew(c.PrivateKey, c.Nonce, [2]*big.Int{c.SaltPublicKey.X, c.SaltPublicKey.Y}, c.Ciphertext)
+				} else {
+					builder.AddNewCommitment(c)
+				}
+			}
+			return builder.Build()
+		}
+		_, err := build(false)
+		if tc.valid {
+			require.Nil(t, err, tc.name)
+		} else {
+			require.True(t, errors.Is(err, core.ErrInvalidCommitment), tc.name)
+		}
+		inputs, err := build(true)
+		require.Nil(t, err)
+
+		data, err := json.Marshal(inputs)
+		require.Nil(t, err)
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		if tc.valid {
+			require.Len(t, evaluation.UnSatisfiedConstraints(), 0, tc.name)
+		} else {
+			require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), tc.name)
+		}
+	}
+}
+
+// The same existing commitment can't be spent twice
+func Test_PrivacyPool_DuplicateInputs(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, newSaltPublicKey, newCiphertext]} = "+testParams.Instance()+";")
+	defer lib.Burn()
+
+	// spends existing twice into values
+	spendTwice := func(existing *core.Commitment, values ...int64) *PrivacyPoolInputsBuilder {
+		tree := newTestStateTree(t, existing)
+		proof, err := tree.GenerateProof(tree.IndexOf(existing.CommitmentRoot), testParams.MaxTreeDepth)
+		require.Nil(t, err)
+		builder := NewPrivacyPoolInputsBuilder(testParams).
+			Scope(existing.Scope).
+			StateTree(tree.Root(), tree.Depth()).
+			AddExistingCommitment(existing, proof).
+			AddExistingCommitment(existing, proof)
+		for _, v := range values {
+			builder.AddNewCommitment(newTestCommitment(t, existing.Scope, v))
+		}
+		return builder
+	}
+	evaluate := func(inputs *PrivacyPoolInputs) Evaluation {
+		data, err := json.Marshal(inputs)
+		require.Nil(t, err)
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		return evaluation
+	}
+
+	scope := randomBelow(t, field.Modulus)
+	existing := newTestCommitment(t, scope, 100)
+	_, err := spendTwice(existing, 200, 0).Build()
+	require.True(t, errors.Is(err, ErrDuplicateCommitment))
+
+	// the circuit rejects the inputs the Go-side validator would've rejected:
+	// spend existing & a void commitment, then swap the void one for existing
+	void := newTestCommitment(t, scope, 0)
+	tree := newTestStateTree(t, existing, void)
+	builder := NewPrivacyPoolInputsBuilder(testParams).
+		Scope(scope).
+		StateTree(tree.Root(), tree.Depth())
+	for _, c := range []*core.Commitment{existing, void} {
+		proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), testParams.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(c, proof)
+	}
+	inputs, err := builder.
+		AddNewCommitment(newTestCommitment(t, scope, 200)).
+		AddNewCommitment(newTestCommitment(t, scope, 0)).
+		Build()
+	require.Nil(t, err)
+	inputs.PrivateKey[1] = inputs.PrivateKey[0]
+	inputs.Nonce[1] = inputs.Nonce[0]
+	inputs.ExSaltPublicKey[1] = inputs.ExSaltPublicKey[0]
+	inputs.ExCiphertext[1] = inputs.ExCiphertext[0]
+	inputs.ExIndex[1] = inputs.ExIndex[0]
+	inputs.ExSiblings[1] = inputs.ExSiblings[0]
+	require.Nil(t, inputs.Validate(testParams))
+	require.True(t, errors.Is(inputs.CheckDuplicates(testParams), ErrDuplicateCommitment))
+	require.NotEqual(t, 0, len(evaluate(inputs).UnSatisfiedConstraints()))
+
+	// a saltPublicKey shifted by the order 2 point (0, -1)
+	// yields the same encryptionKey for an even privateKey
+	// but another nullRoot, the circuit rejects it as not in the prime subgroup
+	even := existing
+	for even.PrivateKey.Bit(0) != 0 {
+		even = newTestCommitment(t, scope, 100)
+	}
+	tree = newTestStateTree(t, even, void)
+	builder = NewPrivacyPoolInputsBuilder(testParams).
+		Scope(scope).
+		StateTree(tree.Root(), tree.Depth())
+	for _, c := range []*core.Commitment{even, void} {
+		proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), testParams.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(c, proof)
+	}
+	inputs, err = builder.
+		AddNewCommitment(newTestCommitment(t, scope, 200)).
+		AddNewCommitment(newTestCommitment(t, scope, 0)).
+		Build()
+	require.Nil(t, err)
+	shifted := &babyjub.Point{
+		X: new(big.Int).Sub(field.Modulus, even.SaltPublicKey.X),
+		Y: new(big.Int).Sub(field.Modulus, even.SaltPublicKey.Y),
+	}
+	require.True(t, shifted.InCurve())
+	require.False(t, shifted.InSubGroup())
+	encryptionKey, err := ecdh.SharedKey(even.PrivateKey, shifted)
+	require.Nil(t, err)
+	require.True(t, encryptionKey.Equal(even.EncryptionKey))
+
+	inputs.PrivateKey[1] = inputs.PrivateKey[0]
+	inputs.Nonce[1] = inputs.Nonce[0]
+	inputs.ExSaltPublicKey[1] = [2]*big.Int{shifted.X, shifted.Y}
+	inputs.ExCiphertext[1] = inputs.ExCiphertext[0]
+	inputs.ExIndex[1] = inputs.ExIndex[0]
+	inputs.ExSiblings[1] = inputs.ExSiblings[0]
+	require.True(t, errors.Is(inputs.Validate(testParams), babyjub.ErrPointNotInSubGroup))
+	require.True(t, errors.Is(inputs.CheckDuplicates(testParams), ErrInvalidInputs))
+	require.NotEqual(t, 0, len(evaluate(inputs).UnSatisfiedConstraints()))
+
+	// void commitments can be duplicated
+	valid, err := spendTwice(newTestCommitment(t, scope, 0), 0, 0).Build()
+	require.Nil(t, err)
+	require.Len(t, evaluate(valid).UnSatisfiedConstraints(), 0)
+}
@@ -0,0 +1,157 @@
+package keys
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+)
+
+// MinSeedSize is the minimum length of a master seed
+const MinSeedSize = 16
+
+const hierarchyDomain = "privacy-pool-veritas/commitment-keys/v1"
+
+var ErrInvalidSeed = errors.New("invalid master seed")
+
+// CommitmentSecrets are the private values of
+// the commitment derived at Counter
+type CommitmentSecrets struct {
+	Counter uint64
+	// PrivateKey & Nonce are the privateKey
+	// & nonce signals of the commitment
+	PrivateKey     *big.Int
+	Nonce          *big.Int
+	SaltPrivateKey *big.Int
+}
+
+// Hierarchy derives the secrets of every commitment
+// of a scope from one master seed and a counter:
+//
+//	material = blake512(domain || len(seed) || seed || scope || counter || label)
+//
+// privateKey & saltPrivateKey are the scalars (DeriveScalar)
+// of the first 32 bytes of their material, the nonce is
+// the first 16 bytes of its material (a 128 bits value)
+type Hierarchy struct {
+	seed  []byte
+	scope *big.Int
+}
+
+func NewHierarchy(seed []byte, scope *big.Int) (*Hierarchy, error) {
+	if len(seed) < MinSeedSize {
+		return nil, fmt.Errorf("%w: expected at least %d bytes, got %d", ErrInvalidSeed, MinSeedSize, len(seed))
+	}
+	if !field.IsCanonical(scope) {
+		return nil, fmt.Errorf("%w: scope is not a field element", ErrInvalidSeed)
+	}
+	return &Hierarchy{seed: append([]byte{}, seed...), scope: new(big.Int).Set(scope)}, nil
+}
+
+// Derive returns the secrets of the commitment at counter
+func (h *Hierarchy) Derive(counter uint64) *CommitmentSecrets {
+	// material is never empty, DeriveScalar can't fail
+	privateKey, _ := DeriveScalar(h.material(counter, "privateKey")[:32])
+	saltPrivateKey, _ := DeriveScalar(h.material(counter, "saltPrivateKey")[:32])
+	return &CommitmentSecrets{
+		Counter:        counter,
+		PrivateKey:     privateKey,
+		Nonce:          new(big.Int).SetBytes(h.material(counter, "nonce")[:16]),
+		SaltPrivateKey: saltPrivateKey,
+	}
+}
+
+// Inputs returns the privateKey & nonce signals of the
+// commitments at counters, in the order of the counters
+// (existing commitments first, then new commitments)
+func (h *Hierarchy) Inputs(counters ...uint64) (privateKey, nonce []*big.Int) {
+	for _, counter := range counters {
+		s := h.Derive(counter)
+		privateKey = append(privateKey, s.PrivateKey)
+		nonce = append(nonce, s.Nonce)
+	}
+	return privateKey, nonce
+}
+
+// NewCommitment builds the commitment of value at counter (see core.NewCommitment)
+func (h *Hierarchy) NewCommitment(counter uint64, value *big.Int, data ...*big.Int) (*core.Commitment, error) {
+	s := h.Derive(counter)
+	return core.NewCommitment(h.scope, value, s.PrivateKey, s.SaltPrivateKey, s.Nonce, data...)
+}
+
+// Recovered is a published commitment derived at Counter
+type Recovered struct {
+	Counter uint64
+	core.ScanResult
+}
+
+// Recover rescans the published commitments for the commitments
+// derived by the hierarchy, walking the counters from 0 until gapLimit
+// consecutive counters have no published commitment.
+// Commitments are found by their derived saltPublicKey & then
+// decrypted with the derived privateKey & nonce
+func (h *Hierarchy) Recover(published []core.PublishedCommitment, tupleLen, gapLimit int) ([]Recovered, error) {
+	if gapLimit <= 0 {
+		return nil, fmt.Errorf("gap limit must be positive, got %d", gapLimit)
+	}
+	bySalt := make(map[string][]core.PublishedCommitment)
+	for _, c := range published {
+		if c.SaltPublicKey != nil {
+			key := pointKey(c.SaltPublicKey)
+			bySalt[key] = append(bySalt[key], c)
+		}
+	}
+
+	var (
+		recovered []Recovered
+		gap       int
+	)
+	for counter := uint64(0); gap < gapLimit; counter++ {
+		s := h.Derive(counter)
+		saltPublicKey, err := babyjub.PrivToPub(s.SaltPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, c := range bySalt[pointKey(saltPublicKey)] {
+			commitment, err := core.OpenCommitment(s.PrivateKey, c.SaltPublicKey, s.Nonce, c.Ciphertext, tupleLen)
+			if err != nil || commitment.Scope.Cmp(h.scope) != 0 {
+				continue
+			}
+			found = true
+			recovered = append(recovered, Recovered{
+				Counter:    counter,
+				ScanResult: core.ScanResult{Index: c.Index, Commitment: commitment},
+			})
+		}
+		if found {
+			gap = 0
+		} else {
+			gap++
+		}
+	}
+	return recovered, nil
+}
+
+func (h *Hierarchy) material(counter uint64, label string) []byte {
+	var (
+		buf   []byte
+		scope = make([]byte, 32)
+	)
+	buf = append(buf, hierarchyDomain...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(h.seed)))
+	buf = append(buf, h.seed...)
+	buf = append(buf, h.scope.FillBytes(scope)...)
+	buf = binary.BigEndian.AppendUint64(buf, counter)
+	buf = append(buf, label...)
+	digest := blake512(buf)
+	return digest[:]
+}
+
+func pointKey(p *babyjub.Point) string {
+	return p.X.Text(16) + "," + p.Y.Text(16)
+}
@@ -0,0 +1,127 @@
+package keys
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+	"github.com/test-go/testify/require"
+)
+
+func newTestHierarchy(t *testing.T) (*Hierarchy, []byte, *big.Int) {
+	seed := make([]byte, 32)
+	_, err := rand.Read(seed)
+	require.Nil(t, err)
+	scope, err := rand.Int(rand.Reader, field.Modulus)
+	require.Nil(t, err)
+	h, err := NewHierarchy(seed, scope)
+	require.Nil(t, err)
+	return h, seed, scope
+}
+
+func Test_Hierarchy_Derive(t *testing.T) {
+	h, seed, scope := newTestHierarchy(t)
+	two128 := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	seen := make(map[string]bool)
+	for counter := uint64(0); counter < 16; counter++ {
+		s := h.Derive(counter)
+		require.True(t, s.PrivateKey.Cmp(babyjub.SubOrder) < 0)
+		require.True(t, s.SaltPrivateKey.Cmp(babyjub.SubOrder) < 0)
+		require.True(t, s.Nonce.Cmp(two128) < 0)
+		for _, v := range []*big.Int{s.PrivateKey, s.SaltPrivateKey} {
+			require.False(t, seen[v.String()])
+			seen[v.String()] = true
+		}
+	}
+
+	// deterministic for the same seed & scope only
+	again, err := NewHierarchy(seed, scope)
+	require.Nil(t, err)
+	require.Equal(t, h.Derive(3), again.Derive(3))
+	other, err := NewHierarchy(seed, new(big.Int).Add(scope, big.NewInt(1)))
+	require.Nil(t, err)
+	require.NotEqual(t, h.Derive(3).PrivateKey, other.Derive(3).PrivateKey)
+
+	_, err = NewHierarchy(seed[:MinSeedSize-1], scope)
+	require.True(t, errors.Is(err, ErrInvalidSeed))
+	_, err = NewHierarchy(seed, field.Modulus)
+	require.True(t, errors.Is(err, ErrInvalidSeed))
+}
+
+func Test_Hierarchy_Recover(t *testing.T) {
+	var (
+		h, _, scope = newTestHierarchy(t)
+		params      = privacypool.PrivacyPoolParams{MaxTreeDepth: 4, CipherLen: 7, TupleLen: 4, NExisting: 2, NNew: 2}
+		tree        = merkletree.NewLeanIMT()
+		published   []core.PublishedCommitment
+	)
+	publish := func(c *core.Commitment) {
+		published = append(published, core.PublishedCommitment{
+			Index:         tree.Size(),
+			SaltPublicKey: c.SaltPublicKey,
+			Ciphertext:    c.Ciphertext,
+		})
+		require.Nil(t, tree.Insert(c.CommitmentRoot))
+	}
+	// counters 0, 1, 2 & 5 among commitments of other seeds
+	for _, counter := range []uint64{0, 1, 2, 5} {
+		c, err := h.NewCommitment(counter, big.NewInt(int64(counter+1)))
+		require.Nil(t, err)
+		publish(c)
+		other, _, _ := newTestHierarchy(t)
+		c, err = other.NewCommitment(counter, big.NewInt(1))
+		require.Nil(t, err)
+		publish(c)
+	}
+
+	recovered, err := h.Recover(published, params.TupleLen, 3)
+	require.Nil(t, err)
+	require.Len(t, recovered, 4)
+	for i, counter := range []uint64{0, 1, 2, 5} {
+		require.Equal(t, counter, recovered[i].Counter)
+		require.Equal(t, 2*i, recovered[i].Index)
+		require.Equal(t, int64(counter+1), recovered[i].Commitment.Value.Int64())
+	}
+
+	// the gap between 2 & 5 exceeds the gap limit
+	recovered, err = h.Recover(published, params.TupleLen, 2)
+	require.Nil(t, err)
+	require.Len(t, recovered, 3)
+
+	_, err = h.Recover(published, params.TupleLen, 0)
+	require.NotNil(t, err)
+
+	// the derived secrets fill the privateKey & nonce signals directly:
+	// spend counters 0 & 1 into new commitments at counters 6 & 7
+	builder := privacypool.NewPrivacyPoolInputsBuilder(params).
+		Scope(scope).
+		Context(big.NewInt(1)).
+		ExternIO(big.NewInt(0), big.NewInt(0)).
+		StateTree(tree.Root(), tree.Depth())
+	for _, r := range recovered[:2] {
+		proof, err := tree.GenerateProof(r.Index, params.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(r.Commitment, proof)
+	}
+	for _, n := range []struct {
+		counter uint64
+		value   int64
+	}{{6, 3}, {7, 0}} {
+		c, err := h.NewCommitment(n.counter, big.NewInt(n.value))
+		require.Nil(t, err)
+		builder.AddNewCommitment(c)
+	}
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+
+	privateKey, nonce := h.Inputs(0, 1)
+	require.Equal(t, privateKey, inputs.PrivateKey[:2])
+	require.Equal(t, nonce, inputs.Nonce[:2])
+}
@@ -0,0 +1,174 @@
+package keys
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+)
+
+// Native (Go) BabyJubJub keys matching the BabyPrivToPubKey,
+// Ecdh & BabyCheck templates. Private keys are hashed & pruned
+// into scalars (deriveScalar of zk-kit) before being used
+// as the privateKey signal of the templates.
+
+// PrivateKeySize is the length of generated private keys
+const PrivateKeySize = 32
+
+var (
+	ErrInvalidPrivateKey = errors.New("private key must not be empty")
+	ErrInvalidPoint      = errors.New("invalid BabyJubJub point")
+)
+
+// KeyPair holds a private key, its scalar & public key
+type KeyPair struct {
+	PrivateKey []byte
+	Scalar     *big.Int
+	PublicKey  *babyjub.Point
+}
+
+// GenerateKey returns a key pair of a random private key read from r,
+// crypto/rand is used if r is nil
+func GenerateKey(r io.Reader) (*KeyPair, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	privateKey := make([]byte, PrivateKeySize)
+	if _, err := io.ReadFull(r, privateKey); err != nil {
+		return nil, err
+	}
+	return NewKeyPair(privateKey)
+}
+
+// NewKeyPair derives the scalar & public key of privateKey
+func NewKeyPair(privateKey []byte) (*KeyPair, error) {
+	scalar, err := DeriveScalar(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := PublicKey(scalar)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		PrivateKey: append([]byte{}, privateKey...),
+		Scalar:     scalar,
+		PublicKey:  publicKey,
+	}, nil
+}
+
+// SharedKey is the ECDH shared key of the key pair & publicKey
+func (k *KeyPair) SharedKey(publicKey *babyjub.Point) (*babyjub.Point, error) {
+	return SharedKey(k.Scalar, publicKey)
+}
+
+// DeriveScalar hashes & prunes privateKey into a scalar
+// of the prime subgroup (deriveScalar of zk-kit):
+// the first 32 bytes of blake512(privateKey) are pruned,
+// read as a little endian integer, shifted right by 3 & reduced
+func DeriveScalar(privateKey []byte) (*big.Int, error) {
+	if len(privateKey) == 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	hash := blake512(privateKey)
+	buf := hash[:32]
+	buf[0] &= 0xf8
+	buf[31] &= 0x7f
+	buf[31] |= 0x40
+
+	scalar := new(big.Int).SetBytes(reversed(buf))
+	scalar.Rsh(scalar, 3)
+	return scalar.Mod(scalar, babyjub.SubOrder), nil
+}
+
+// PublicKey mirrors BabyPrivToPubKey: scalar * Base8
+func PublicKey(scalar *big.Int) (*babyjub.Point, error) {
+	return babyjub.PrivToPub(scalar)
+}
+
+// SharedKey mirrors Ecdh: scalar * publicKey,
+// publicKey has to pass Validate
+func SharedKey(scalar *big.Int, publicKey *babyjub.Point) (*babyjub.Point, error) {
+	if err := Validate(publicKey); err != nil {
+		return nil, err
+	}
+	return ecdh.SharedKey(scalar, publicKey)
+}
+
+// Validate mirrors BabyCheck: p must be on the curve
+func Validate(p *babyjub.Point) error {
+	if !p.InCurve() {
+		return fmt.Errorf("%w: %v", ErrInvalidPoint, babyjub.ErrPointNotOnCurve)
+	}
+	return nil
+}
+
+// Compress packs p as circomlibjs does: y in little endian
+// with the most significant bit set if x is "negative" (x > (q-1)/2)
+func Compress(p *babyjub.Point) ([32]byte, error) {
+	var out [32]byte
+	if err := Validate(p); err != nil {
+		return out, err
+	}
+	copy(out[:], reversed(p.Y.FillBytes(make([]byte, 32))))
+	if isNegative(p.X) {
+		out[31] |= 0x80
+	}
+	return out, nil
+}
+
+// Decompress unpacks a point packed by Compress
+func Decompress(packed [32]byte) (*babyjub.Point, error) {
+	buf := packed
+	negative := buf[31]&0x80 != 0
+	buf[31] &= 0x7f
+
+	y := new(big.Int).SetBytes(reversed(buf[:]))
+	if !field.IsCanonical(y) {
+		return nil, fmt.Errorf("%w: y is not a field element", ErrInvalidPoint)
+	}
+	// x^2 = (1 - y^2) / (a - d*y^2)
+	var (
+		y2  = field.Reduce(new(big.Int).Mul(y, y))
+		num = field.Reduce(new(big.Int).Sub(big.NewInt(1), y2))
+		den = field.Reduce(new(big.Int).Sub(babyjub.A, new(big.Int).Mul(babyjub.D, y2)))
+	)
+	if den.Sign() == 0 {
+		return nil, fmt.Errorf("%w: no x for y", ErrInvalidPoint)
+	}
+	x2 := field.Reduce(new(big.Int).Mul(num, new(big.Int).ModInverse(den, field.Modulus)))
+	x := new(big.Int).ModSqrt(x2, field.Modulus)
+	if x == nil {
+		return nil, fmt.Errorf("%w: no x for y", ErrInvalidPoint)
+	}
+	if isNegative(x) {
+		x = field.Reduce(x.Neg(x))
+	}
+	if negative {
+		x = field.Reduce(x.Neg(x))
+	}
+	p := &babyjub.Point{X: x, Y: y}
+	if err := Validate(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// isNegative reports whether x > (q-1)/2
+func isNegative(x *big.Int) bool {
+	half := new(big.Int).Rsh(field.Modulus, 1)
+	return x.Cmp(half) > 0
+}
+
+func reversed(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, v := range b {
+		out[len(b)-1-i] = v
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package keys
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+// BLAKE-512 test vectors of the specification
+func Test_Blake512(t *testing.T) {
+	for _, v := range []struct {
+		data   []byte
+		digest string
+	}{
+		{[]byte{}, "a8cfbbd73726062df0c6864dda65defe58ef0cc52a5625090fa17601e1eecd1b628e94f396ae402a00acc9eab77b4d4c2e852aaaa25a636d80af3fc7913ef5b8"},
+		{[]byte{0}, "97961587f6d970faba6d2478045de6d1fabd09b61ae50932054d52bc29d31be4ff9102b9f69e2bbdb83be13d4b9c06091e5fa0b48bd081b634058be0ec49beb3"},
+		{make([]byte, 144), "313717d608e9cf758dcb1eb0f0c3cf9fc150b2d500fb33f51c52afc99d358a2f1374b8a38bba7974e7f6ef79cab16f22ce1e649d6e01ad9589c213045d545dde"},
+	} {
+		digest := blake512(v.data)
+		require.Equal(t, v.digest, hex.EncodeToString(digest[:]), "%d bytes", len(v.data))
+	}
+}
+
+func Test_KeyPair(t *testing.T) {
+	alice, err := GenerateKey(nil)
+	require.Nil(t, err)
+	bob, err := GenerateKey(nil)
+	require.Nil(t, err)
+
+	// deterministic & within the prime subgroup
+	again, err := NewKeyPair(alice.PrivateKey)
+	require.Nil(t, err)
+	require.Equal(t, alice.Scalar, again.Scalar)
+	require.True(t, alice.PublicKey.Equal(again.PublicKey))
+	require.True(t, alice.Scalar.Cmp(babyjub.SubOrder) < 0)
+
+	ab, err := alice.SharedKey(bob.PublicKey)
+	require.Nil(t, err)
+	ba, err := bob.SharedKey(alice.PublicKey)
+	require.Nil(t, err)
+	require.True(t, ab.Equal(ba))
+
+	_, err = NewKeyPair(nil)
+	require.True(t, errors.Is(err, ErrInvalidPrivateKey))
+	_, err = GenerateKey(bytes.NewReader(make([]byte, PrivateKeySize-1)))
+	require.NotNil(t, err)
+
+	offCurve := &babyjub.Point{X: big.NewInt(1), Y: big.NewInt(1)}
+	_, err = alice.SharedKey(offCurve)
+	require.True(t, errors.Is(err, ErrInvalidPoint))
+}
+
+// prv2pub vector of the circomlibjs eddsa tests
+// ("Sign (using Poseidon) a single 10 bytes from 0 to 9")
+func Test_KeyPair_KnownAnswer(t *testing.T) {
+	privateKey, err := hex.DecodeString("0001020304050607080900010203040506070809000102030405060708090001")
+	require.Nil(t, err)
+	k, err := NewKeyPair(privateKey)
+	require.Nil(t, err)
+	require.Equal(t, "13277427435165878497778222415993513565335242147425444199013288855685581939618", k.PublicKey.X.String())
+	require.Equal(t, "13622229784656158136036771217484571176836296686641868549125388198837476602820", k.PublicKey.Y.String())
+}
+
+func Test_Compress(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		k, err := GenerateKey(nil)
+		require.Nil(t, err)
+		packed, err := Compress(k.PublicKey)
+		require.Nil(t, err)
+		p, err := Decompress(packed)
+		require.Nil(t, err)
+		require.True(t, k.PublicKey.Equal(p))
+	}
+
+	identity, err := Compress(babyjub.Identity())
+	require.Nil(t, err)
+	p, err := Decompress(identity)
+	require.Nil(t, err)
+	require.True(t, babyjub.Identity().Equal(p))
+
+	_, err = Compress(&babyjub.Point{X: big.NewInt(1), Y: big.NewInt(1)})
+	require.True(t, errors.Is(err, ErrInvalidPoint))
+	var invalid [32]byte
+	for i := range invalid {
+		invalid[i] = 0xff
+	}
+	_, err = Decompress(invalid)
+	require.True(t, errors.Is(err, ErrInvalidPoint))
+}
+
+// Test_Keys_Circuit runs the key derivation against
+// BabyPrivToPubKey, Ecdh & BabyCheck on random keys
+func Test_Keys_Circuit(t *testing.T) {
+	pkgs, err := privacypool.Resolve("babyjub.BabyJubCircuitPkg", "ecdh.EcdhCircuitPkg")
+	require.Nil(t, err)
+
+	lib := NewEmptyLibrary()
+	defer lib.Burn()
+	reports, err := lib.Compile(append(pkgs, CircuitPkg{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs: []Program{
+			{
+				Identity: "KeysCheck",
+				Src: `
+				template KeysCheck() {
+                    input signal privateKey;
+                    input signal publicKey[2];
+                    output signal derivedPublicKey[2];
+                    output signal sharedKey[2];
+
+                    BabyCheck()(publicKey[0], publicKey[1]);
+
+                    var pk[2] = BabyPrivToPubKey()(privateKey);
+                    derivedPublicKey <== pk;
+                    var shared[2] = Ecdh()(privateKey, publicKey);
+                    sharedKey <== shared;
+                }`,
+			},
+			{Identity: "main", Src: "component main = KeysCheck();"},
+		},
+	})...)
+	require.Nil(t, err)
+	require.False(t, harness.HasErrors(reports), reports.String())
+
+	evaluate := func(scalar *big.Int, publicKey *babyjub.Point) Evaluation {
+		evaluation, err := lib.Evaluate([]byte(fmt.Sprintf(
+			`{"privateKey":"%s","publicKey":["%s","%s"]}`, scalar, publicKey.X, publicKey.Y,
+		)))
+		require.Nil(t, err)
+		return evaluation
+	}
+	for i := 0; i < 8; i++ {
+		alice, err := GenerateKey(nil)
+		require.Nil(t, err)
+		bob, err := GenerateKey(nil)
+		require.Nil(t, err)
+		shared, err := alice.SharedKey(bob.PublicKey)
+		require.Nil(t, err)
+
+		evaluation := evaluate(alice.Scalar, bob.PublicKey)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+		require.Equal(t, alice.PublicKey.X, harness.Signal(t, evaluation, "main.derivedPublicKey[0]"))
+		require.Equal(t, alice.PublicKey.Y, harness.Signal(t, evaluation, "main.derivedPublicKey[1]"))
+		require.Equal(t, shared.X, harness.Signal(t, evaluation, "main.sharedKey[0]"))
+		require.Equal(t, shared.Y, harness.Signal(t, evaluation, "main.sharedKey[1]"))
+	}
+
+	// BabyCheck rejects the points Validate rejects
+	alice, err := GenerateKey(nil)
+	require.Nil(t, err)
+	offCurve := &babyjub.Point{X: alice.PublicKey.X, Y: new(big.Int).Add(alice.PublicKey.Y, big.NewInt(1))}
+	require.NotNil(t, Validate(offCurve))
+	require.NotEqual(t, 0, len(evaluate(alice.Scalar, offCurve).UnSatisfiedConstraints()))
+}
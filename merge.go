@@ -0,0 +1,183 @@
+package privacypool
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+)
+
+// PrivacyPoolMergeParams are the template parameters of
+// PrivacyPoolMerge(maxTreeDepth, cipherLen, tupleLen, nExisting)
+type PrivacyPoolMergeParams struct {
+	MaxTreeDepth int
+	CipherLen    int
+	TupleLen     int
+	NExisting    int
+}
+
+// Instance returns the template instantiation of the params
+func (p PrivacyPoolMergeParams) Instance() string {
+	return fmt.Sprintf("PrivacyPoolMerge(%d, %d, %d, %d)",
+		p.MaxTreeDepth, p.CipherLen, p.TupleLen, p.NExisting)
+}
+
+// PrivacyPoolParams returns the PrivacyPool params
+// with a single new commitment the merge inputs are built with
+func (p PrivacyPoolMergeParams) PrivacyPoolParams() PrivacyPoolParams {
+	return PrivacyPoolParams{
+		MaxTreeDepth: p.MaxTreeDepth,
+		CipherLen:    p.CipherLen,
+		TupleLen:     p.TupleLen,
+		NExisting:    p.NExisting,
+		NNew:         1,
+	}
+}
+
+// PublicSignalLayout returns the layout of the public signal vector
+// of PrivacyPoolMerge for params, i.e. the PrivacyPool one without externIO
+func (p PrivacyPoolMergeParams) PublicSignalLayout() []SignalLayout {
+	var (
+		layout []SignalLayout
+		index  int
+	)
+	for _, s := range p.PrivacyPoolParams().PublicSignalLayout() {
+		if s.Name == "externIO" {
+			continue
+		}
+		s.Index = index
+		layout = append(layout, s)
+		index += s.Size()
+	}
+	return layout
+}
+
+// PublicSignalsLength is the length of the public signal vector
+func (p PrivacyPoolMergeParams) PublicSignalsLength() int {
+	layout := p.PublicSignalLayout()
+	last := layout[len(layout)-1]
+	return last.Index + last.Size()
+}
+
+// PrivacyPoolMergeInputs holds every input signal of PrivacyPoolMerge,
+// i.e. PrivacyPoolInputs with a single new commitment & no externIO
+type PrivacyPoolMergeInputs struct {
+	PrivacyPoolInputs
+}
+
+// BuildMerge is Build for PrivacyPoolMerge,
+// the builder params must have a single new commitment
+// and externIO is left unset (or zero)
+func (b *PrivacyPoolInputsBuilder) BuildMerge() (*PrivacyPoolMergeInputs, error) {
+	if b.params.NNew != 1 {
+		return nil, fmt.Errorf("%w: merge expects 1 new commitment, params have %d", ErrInvalidInputs, b.params.NNew)
+	}
+	for i, v := range b.inputs.ExternIO {
+		if v != nil && v.Sign() != 0 {
+			return nil, fmt.Errorf("%w: externIO[%d] must be zero for a merge", ErrInvalidInputs, i)
+		}
+	}
+	b.ExternIO(big.NewInt(0), big.NewInt(0))
+	inputs, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &PrivacyPoolMergeInputs{PrivacyPoolInputs: *inputs}, nil
+}
+
+// MarshalJSON encodes the inputs as the input JSON of PrivacyPoolMerge
+// (see PrivacyPoolInputs.MarshalJSON)
+func (in PrivacyPoolMergeInputs) MarshalJSON() ([]byte, error) {
+	return marshalSignals(in.PrivacyPoolInputs, []string{"externIO"}, nil)
+}
+
+// Merge consolidates existing commitments (members of tree)
+// into a single new commitment of their total value owned by privateKey.
+// Fewer than params.NExisting commitments are padded with void commitments.
+// It returns the inputs of PrivacyPoolMerge along with the merged commitment
+func Merge(
+	params PrivacyPoolMergeParams,
+	scope, context *big.Int,
+	tree *merkletree.LeanIMT,
+	existing []*core.Commitment,
+	privateKey, nonce *big.Int,
+) (*PrivacyPoolMergeInputs, *core.Commitment, error) {
+	if len(existing) == 0 || len(existing) > params.NExisting {
+		return nil, nil, fmt.Errorf("%w: merge expects 1 to %d existing commitments, got %d",
+			ErrInvalidInputs, params.NExisting, len(existing))
+	}
+
+	var (
+		builder = NewPrivacyPoolInputsBuilder(params.PrivacyPoolParams()).
+			Scope(scope).
+			Context(context).
+			StateTree(tree.Root(), tree.Depth())
+		total = big.NewInt(0)
+	)
+	for i, c := range existing {
+		if c == nil {
+			return nil, nil, fmt.Errorf("%w: existing commitment %d is nil", ErrInvalidInputs, i)
+		}
+		index := tree.IndexOf(c.CommitmentRoot)
+		if index < 0 {
+			return nil, nil, fmt.Errorf("%w: existing commitment %d is not in the state tree", ErrInvalidInputs, i)
+		}
+		proof, err := tree.GenerateProof(index, params.MaxTreeDepth)
+		if err != nil {
+			return nil, nil, err
+		}
+		builder.AddExistingCommitment(c, proof)
+		total.Add(total, c.Value)
+	}
+	if total.BitLen() > core.ValueBits {
+		return nil, nil, fmt.Errorf("%w: merged value is not a %d bits value", ErrInvalidInputs, core.ValueBits)
+	}
+	// void commitments are not checked against the state tree
+	for i := len(existing); i < params.NExisting; i++ {
+		void, err := newCommitment(scope, big.NewInt(0), privateKey, nonce, params.TupleLen)
+		if err != nil {
+			return nil, nil, err
+		}
+		builder.AddExisting(
+			void.PrivateKey, void.Nonce,
+			[2]*big.Int{void.SaltPublicKey.X, void.SaltPublicKey.Y},
+			void.Ciphertext,
+			big.NewInt(0),
+			zeros(params.MaxTreeDepth),
+		)
+	}
+
+	merged, err := newCommitment(scope, total, privateKey, nonce, params.TupleLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs, err := builder.AddNewCommitment(merged).BuildMerge()
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputs, merged, nil
+}
+
+// newCommitment is a commitment of value with
+// a random salt & the tuple zero padded to tupleLen
+func newCommitment(scope, value, privateKey, nonce *big.Int, tupleLen int) (*core.Commitment, error) {
+	if tupleLen < core.TupleLen {
+		return nil, fmt.Errorf("%w: tupleLen: expected at least %d, got %d", ErrInvalidInputs, core.TupleLen, tupleLen)
+	}
+	saltPrivateKey, err := rand.Int(rand.Reader, babyjub.SubOrder)
+	if err != nil {
+		return nil, err
+	}
+	return core.NewCommitment(scope, value, privateKey, saltPrivateKey, nonce, zeros(tupleLen-core.TupleLen)...)
+}
+
+func zeros(n int) []*big.Int {
+	out := make([]*big.Int, n)
+	for i := range out {
+		out[i] = big.NewInt(0)
+	}
+	return out
+}
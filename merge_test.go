@@ -0,0 +1,129 @@
+package privacypool
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+func Test_PrivacyPoolMerge(t *testing.T) {
+	var (
+		params     = PrivacyPoolMergeParams{MaxTreeDepth: 4, CipherLen: 7, TupleLen: 4, NExisting: 8}
+		poolParams = params.PrivacyPoolParams()
+	)
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, existingStateRoot, newSaltPublicKey, newCiphertext]} = "+params.Instance()+";")
+	defer lib.Burn()
+
+	var (
+		scope      = randomBelow(t, field.Modulus)
+		context    = randomBelow(t, field.Modulus)
+		privateKey = randomBelow(t, babyjub.SubOrder)
+		nonce      = randomBelow(t, new(big.Int).Lsh(big.NewInt(1), 128))
+		dust       = make([]*core.Commitment, 8)
+	)
+	for i := range dust {
+		dust[i] = newTestCommitment(t, scope, int64(i+1))
+	}
+	tree := newTestStateTree(t, dust...)
+
+	evaluate := func(inputs *PrivacyPoolMergeInputs) Evaluation {
+		data, err := json.Marshal(inputs)
+		require.Nil(t, err)
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		return evaluation
+	}
+
+	// 8 dust commitments & 5 padded with void commitments
+	for _, existing := range [][]*core.Commitment{dust, dust[3:]} {
+		inputs, merged, err := Merge(params, scope, context, tree, existing, privateKey, nonce)
+		require.Nil(t, err)
+		total := int64(0)
+		for _, c := range existing {
+			total += c.Value.Int64()
+		}
+		require.Equal(t, big.NewInt(total), merged.Value)
+
+		evaluation := evaluate(inputs)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+		outputs := evaluationOutputs(t, evaluation, poolParams)
+		require.Nil(t, VerifyOutputs(poolParams, &inputs.PrivacyPoolInputs, outputs))
+		require.Equal(t, merged.CommitmentRoot, outputs.NewCommitmentRoot[8])
+		for i, c := range existing {
+			require.Equal(t, c.NullRoot, outputs.NewNullRoot[i])
+		}
+
+		// the merged commitment is spendable by privateKey
+		opened, err := core.OpenCommitment(privateKey, merged.SaltPublicKey, nonce, merged.Ciphertext, params.TupleLen)
+		require.Nil(t, err)
+		require.Equal(t, merged.Value, opened.Value)
+	}
+
+	// the merged commitment must hold the total value
+	builder := NewPrivacyPoolInputsBuilder(poolParams).
+		Scope(scope).
+		Context(context).
+		StateTree(tree.Root(), tree.Depth())
+	for _, c := range dust {
+		proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), params.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(c, proof)
+	}
+	inputs, err := builder.AddNewCommitment(newTestCommitment(t, scope, 37)).BuildMerge()
+	require.Nil(t, err)
+	require.NotEqual(t, 0, len(evaluate(inputs).UnSatisfiedConstraints()))
+
+	// the same commitment can't be merged twice
+	_, _, err = Merge(params, scope, context, tree, []*core.Commitment{dust[0], dust[1], dust[0]}, privateKey, nonce)
+	require.True(t, errors.Is(err, ErrDuplicateCommitment))
+	// spend dust[0] in place of dust[1] with a merged commitment of
+	// the resulting total (36 - 2 + 1) so that only DistinctNullRoots rejects it
+	inputs, _, err = Merge(params, scope, context, tree, dust, privateKey, nonce)
+	require.Nil(t, err)
+	inputs.ExCiphertext[1], inputs.ExSaltPublicKey[1] = inputs.ExCiphertext[0], inputs.ExSaltPublicKey[0]
+	inputs.ExIndex[1], inputs.ExSiblings[1] = inputs.ExIndex[0], inputs.ExSiblings[0]
+	inputs.PrivateKey[1], inputs.Nonce[1] = inputs.PrivateKey[0], inputs.Nonce[0]
+	respent := newTestCommitment(t, scope, 36-2+1)
+	inputs.NewSaltPublicKey[0] = [2]*big.Int{respent.SaltPublicKey.X, respent.SaltPublicKey.Y}
+	inputs.NewCiphertext[0] = respent.Ciphertext
+	inputs.PrivateKey[8], inputs.Nonce[8] = respent.PrivateKey, respent.Nonce
+	require.NotEqual(t, 0, len(evaluate(inputs).UnSatisfiedConstraints()))
+
+	// invalid merges
+	_, _, err = Merge(params, scope, context, tree, nil, privateKey, nonce)
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	_, _, err = Merge(params, scope, context, tree, append(dust, dust[0]), privateKey, nonce)
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	_, _, err = Merge(params, scope, context, tree, []*core.Commitment{newTestCommitment(t, scope, 1)}, privateKey, nonce)
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	// the merged value must fit in core.ValueBits bits
+	maxValue := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), core.ValueBits), big.NewInt(1))
+	large := make([]*core.Commitment, 2)
+	for i := range large {
+		large[i], err = core.NewCommitment(scope, maxValue, randomBelow(t, babyjub.SubOrder), randomBelow(t, babyjub.SubOrder), nonce)
+		require.Nil(t, err)
+	}
+	_, _, err = Merge(params, scope, context, newTestStateTree(t, large...), large, privateKey, nonce)
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	require.Contains(t, err.Error(), "merged value")
+	_, err = NewPrivacyPoolInputsBuilder(poolParams).ExternIO(big.NewInt(1), big.NewInt(0)).BuildMerge()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	_, err = NewPrivacyPoolInputsBuilder(testParams).BuildMerge()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+
+	// externIO isn't part of the public signals
+	layout := params.PublicSignalLayout()
+	require.Equal(t, poolParams.PublicSignalsLength()-2, params.PublicSignalsLength())
+	for _, s := range layout {
+		require.NotEqual(t, "externIO", s.Name)
+	}
+	require.Equal(t, "existingStateRoot", layout[6].Name)
+	require.Equal(t, 3*9+3, layout[6].Index)
+}
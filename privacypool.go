@@ -3,6 +3,17 @@ package privacypool
 import (
 	_ "embed"
 
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/bit"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/comparators"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/logic"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/multiplexer"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/scalar"
+	_ "github.com/0xBow-io/privacy-pool-veritas/common/utils"
 	"github.com/0xBow-io/privacy-pool-veritas/core"
 
 	. "github.com/0xBow-io/veritas"
@@ -11,37 +22,65 @@ import (
 // PrivacyPoolCircuitPkg is the package containg all core circuit
 // blocks required to build a complete PrivacyPool circuit
 //
-// You will still need to import the following circuit pkgs
-// to meet dependencies:
-// - BinSumCircuitPkg && BitifyCircuitPkg
-// - CircuitUtilsPkg
-// - ComparatorsCircuitPkg & SafeComparatorsCircuitPkg
-// - PoseidonCircuitPkg with PoseidonDecrypt
-// - MerkleTreeCircuitPkg
-// - MultiplexerCircuitPkg
-// - GatesCircuitPkg
-// - BabyJubCircuitPkg
-// - MontGomeryCircuitPkg
-// - EcdhCircuitPkg
-// - EscalarMulCircuitPkg
-
+// Use Bundle to get it along with all of its dependencies
 var PrivacyPoolCircuitPkg = CircuitPkg{
 	TargetVersion: "2.2.0",
 	Field:         "bn128",
 	Programs: []Program{
 		PrivacyPool,
+		PrivacyPoolWithAssociation,
+		PrivacyPoolWithAssets,
+		PrivacyPoolWithRelayer,
+		PrivacyPoolDeposit,
+		PrivacyPoolWithdraw,
+		PrivacyPoolMerge,
 		// Core Circuit Blocks
+		core.VALUE_BITS,
 		core.RecoverCommitmentKeys,
 		core.DecryptCommitment,
 		core.CommitmentOwnershipProof,
 		core.CommitmentMembershipProof,
+		core.CountedValue,
 		core.HandleExistingCommitment,
+		core.HandleAssociatedCommitment,
 		core.HandleNewCommitment,
+		core.HandleExistingAssetCommitment,
+		core.HandleNewAssetCommitment,
+		core.DistinctNullRoots,
 		core.PoseidonDecryptWithoutCheck,
+		core.PoseidonDecryptTemplate,
 		core.PoseidonDecryptIterations,
 	},
 }
 
+func init() {
+	registry.Register("privacypool.PrivacyPoolCircuitPkg", PrivacyPoolCircuitPkg,
+		"bit.BitifyCircuitPkg",
+		"comparators.ComparatorsCircuitPkg",
+		"logic.GatesCircuitPkg",
+		"poseidon.PoseidonCircuitPkg",
+		"merkletree.MerkleTreeCircuitPkg",
+		"babyjub.BabyJubCircuitPkg",
+		"ecdh.EcdhCircuitPkg",
+	)
+}
+
+// Bundle returns PrivacyPoolCircuitPkg along with
+// every circuit pkg it depends on, ready to be compiled:
+//
+//	pkgs, err := privacypool.Bundle()
+//	reports, err := lib.Compile(append(pkgs, main)...)
+func Bundle() ([]CircuitPkg, error) {
+	return Resolve("privacypool.PrivacyPoolCircuitPkg")
+}
+
+// Resolve returns the named circuit pkgs of this module
+// i.e. "bit.BitifyCircuitPkg" along with every circuit pkg they depend on.
+// Importing this package guarantees that all of them are registered.
+func Resolve(names ...string) ([]CircuitPkg, error) {
+	return registry.Resolve(names...)
+}
+
 // TODO:
 // - Add Documentation
 // - Fragment template into smaller components
@@ -88,6 +127,12 @@ var (
             output signal newCommitmentRoot[nExisting+nNew];
             output signal newCommitmentHash[nExisting+nNew];
 
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
             // ensure that External Input & Output
             // fits within the 252 bits
             var n2bIO[2][252];
@@ -98,6 +143,9 @@ var (
             signal _newCommitmentRootOut[nNew+nExisting];
             signal _newCommitmentHashOut[nNew+nExisting];
 
+            // value counted for every existing commitment
+            signal exValue[nExisting];
+
             // get ownership & membership proofs for existing commitments
             // and compute total sum
             signal totalEx[nExisting+1];
@@ -106,7 +154,9 @@ var (
                 var out[4] = HandleExistingCommitment(
                                 maxTreeDepth,
                                 cipherLen,
-                                tupleLen
+                                tupleLen,
+                                valueBits,
+                                checked
                             )(
                                 scope,
                                 existingStateRoot,
@@ -121,8 +171,174 @@ var (
                 _newNullRootOut[i] <== out[0];
                 _newCommitmentRootOut[i] <== out[1];
                 _newCommitmentHashOut[i] <== out[2];
-                totalEx[i+1] <== totalEx[i] + out[3];
+                exValue[i] <== out[3];
+                totalEx[i+1] <== totalEx[i] + exValue[i];
+            }
+
+            // an existing commitment can't be spent twice
+            var exNullRoot[nExisting];
+            for (var i = 0; i < nExisting; i++) {
+                exNullRoot[i] = _newNullRootOut[i];
+            }
+            DistinctNullRoots(nExisting)(exNullRoot, exValue);
+
+            // get ownership for new commitments
+            // and compute total sum
+            signal totalNew[nNew+1];
+            totalNew[0] <== externIO[1];
+            var k = nExisting; // offset for new commitments
+            for (var i = 0; i < nNew; i++) {
+
+                var out[4] = HandleNewCommitment(
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                privateKey[k],
+                                nonce[k],
+                                newSaltPublicKey[i],
+                                newCiphertext[i]
+                            );
+                _newNullRootOut[k] <== out[0];
+                _newCommitmentRootOut[k] <== out[1];
+                _newCommitmentHashOut[k] <== out[2];
+                totalNew[i+1] <== totalNew[i] + out[3];
+                k++;
+            }
+
+            // lastly ensure that all total sums are equal
+            signal sumEqCheck <== IsEqual()(
+                                [
+                                    totalEx[nExisting],
+                                    totalNew[nNew]
+                                ]
+                            );
+            sumEqCheck === 1;
+
+            newNullRoot <== _newNullRootOut;
+            newCommitmentRoot <== _newCommitmentRootOut;
+            newCommitmentHash <== _newCommitmentHashOut;
+
+            // constraint on context
+            signal contextSqrd <== context * context;
+        }
+	`}
+
+	// PrivacyPoolWithAssociation is PrivacyPool with an additional
+	// proof that every (non-void) existing commitment
+	// belongs to the association set committed to by associationRoot
+	PrivacyPoolWithAssociation = Program{
+		Identity: "PrivacyPoolWithAssociation",
+		Src: `
+		template PrivacyPoolWithAssociation(maxTreeDepth, maxAssociationTreeDepth, cipherLen, tupleLen, nExisting, nNew) {
+            /// **** Public Signals ****
+
+            // Scope is the domain identifier
+            // i.e. Keccak256(chainID, contractAddress)
+            input signal scope;
+            // The depth of the State Tree
+            // at which the merkleproofs
+            // were generated
+            input signal actualTreeDepth;
+
+            input signal context;
+            // external input values to existing commitments
+            // external output values from new commitments
+            input signal externIO[2];
+
+            input signal existingStateRoot;
+
+            // Root of the association set tree
+            // with commitmentRoots as leaves
+            // and the depth at which the merkleproofs
+            // were generated
+            input signal associationRoot;
+            input signal actualAssociationTreeDepth;
+
+            input signal newSaltPublicKey[nNew][2];
+            input signal newCiphertext[nNew][cipherLen];
+
+            /// **** End Of Public Signals ****
+
+            /// **** Private Signals ****
+
+            input signal privateKey[nExisting+nNew];
+            input signal nonce[nExisting+nNew];
+
+            input signal exSaltPublicKey[nExisting][2];
+            input signal exCiphertext[nExisting][cipherLen];
+            input signal exIndex[nExisting];
+            input signal exSiblings[nExisting][maxTreeDepth];
+            input signal exAssociationIndex[nExisting];
+            input signal exAssociationSiblings[nExisting][maxAssociationTreeDepth];
+
+            /// **** End Of Private Signals ****
+
+            output signal newNullRoot[nExisting+nNew];
+            output signal newCommitmentRoot[nExisting+nNew];
+            output signal newCommitmentHash[nExisting+nNew];
+
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
+            // ensure that External Input & Output
+            // fits within the 252 bits
+            var n2bIO[2][252];
+            n2bIO[0] = Num2Bits(252)(externIO[0]);
+            n2bIO[1] = Num2Bits(252)(externIO[1]);
+
+            signal _newNullRootOut[nNew+nExisting];
+            signal _newCommitmentRootOut[nNew+nExisting];
+            signal _newCommitmentHashOut[nNew+nExisting];
+
+            // value counted for every existing commitment
+            signal exValue[nExisting];
+
+            // get ownership, membership & association proofs
+            // for existing commitments and compute total sum
+            signal totalEx[nExisting+1];
+            totalEx[0] <== externIO[0];
+            for (var i = 0; i < nExisting; i++) {
+                var out[4] = HandleAssociatedCommitment(
+                                maxTreeDepth,
+                                maxAssociationTreeDepth,
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                existingStateRoot,
+                                actualTreeDepth,
+                                associationRoot,
+                                actualAssociationTreeDepth,
+                                privateKey[i],
+                                nonce[i],
+                                exSaltPublicKey[i],
+                                exCiphertext[i],
+                                exIndex[i],
+                                exSiblings[i],
+                                exAssociationIndex[i],
+                                exAssociationSiblings[i]
+                            );
+                _newNullRootOut[i] <== out[0];
+                _newCommitmentRootOut[i] <== out[1];
+                _newCommitmentHashOut[i] <== out[2];
+                exValue[i] <== out[3];
+                totalEx[i+1] <== totalEx[i] + exValue[i];
+            }
+
+            // an existing commitment can't be spent twice
+            var exNullRoot[nExisting];
+            for (var i = 0; i < nExisting; i++) {
+                exNullRoot[i] = _newNullRootOut[i];
             }
+            DistinctNullRoots(nExisting)(exNullRoot, exValue);
 
             // get ownership for new commitments
             // and compute total sum
@@ -133,7 +349,9 @@ var (
 
                 var out[4] = HandleNewCommitment(
                                 cipherLen,
-                                tupleLen
+                                tupleLen,
+                                valueBits,
+                                checked
                             )(
                                 scope,
                                 privateKey[k],
@@ -165,4 +383,612 @@ var (
             signal contextSqrd <== context * context;
         }
 	`}
+
+	// PrivacyPoolWithAssets is PrivacyPool for asset tagged commitments
+	// [value, scope, secret.x, secret.y, assetId, ...]
+	// where value is conserved per asset rather than in total.
+	// externIO is denominated in externAsset.
+	PrivacyPoolWithAssets = Program{
+		Identity: "PrivacyPoolWithAssets",
+		Src: `
+		template PrivacyPoolWithAssets(maxTreeDepth, cipherLen, tupleLen, nExisting, nNew) {
+            /// **** Public Signals ****
+
+            // Scope is the domain identifier
+            // i.e. Keccak256(chainID, contractAddress)
+            input signal scope;
+            // The depth of the State Tree
+            // at which the merkleproofs
+            // were generated
+            input signal actualTreeDepth;
+
+            input signal context;
+            // external input values to existing commitments
+            // external output values from new commitments
+            input signal externIO[2];
+            // asset identifier of externIO
+            input signal externAsset;
+
+            input signal existingStateRoot;
+            input signal newSaltPublicKey[nNew][2];
+            input signal newCiphertext[nNew][cipherLen];
+
+            /// **** End Of Public Signals ****
+
+            /// **** Private Signals ****
+
+            input signal privateKey[nExisting+nNew];
+            input signal nonce[nExisting+nNew];
+
+            input signal exSaltPublicKey[nExisting][2];
+            input signal exCiphertext[nExisting][cipherLen];
+            input signal exIndex[nExisting];
+            input signal exSiblings[nExisting][maxTreeDepth];
+
+            /// **** End Of Private Signals ****
+
+            output signal newNullRoot[nExisting+nNew];
+            output signal newCommitmentRoot[nExisting+nNew];
+            output signal newCommitmentHash[nExisting+nNew];
+
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
+            // ensure that External Input & Output
+            // fits within the 252 bits
+            var n2bIO[2][252];
+            n2bIO[0] = Num2Bits(252)(externIO[0]);
+            n2bIO[1] = Num2Bits(252)(externIO[1]);
+
+            signal _newNullRootOut[nNew+nExisting];
+            signal _newCommitmentRootOut[nNew+nExisting];
+            signal _newCommitmentHashOut[nNew+nExisting];
+
+            // value & asset of every commitment
+            // existing commitments first
+            signal value[nExisting+nNew];
+            signal asset[nExisting+nNew];
+
+            // get ownership & membership proofs for existing commitments
+            for (var i = 0; i < nExisting; i++) {
+                var out[5] = HandleExistingAssetCommitment(
+                                maxTreeDepth,
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                existingStateRoot,
+                                actualTreeDepth,
+                                privateKey[i],
+                                nonce[i],
+                                exSaltPublicKey[i],
+                                exCiphertext[i],
+                                exIndex[i],
+                                exSiblings[i]
+                            );
+                _newNullRootOut[i] <== out[0];
+                _newCommitmentRootOut[i] <== out[1];
+                _newCommitmentHashOut[i] <== out[2];
+                value[i] <== out[3];
+                asset[i] <== out[4];
+            }
+
+            // an existing commitment can't be spent twice
+            var exNullRoot[nExisting];
+            var exValue[nExisting];
+            for (var i = 0; i < nExisting; i++) {
+                exNullRoot[i] = _newNullRootOut[i];
+                exValue[i] = value[i];
+            }
+            DistinctNullRoots(nExisting)(exNullRoot, exValue);
+
+            // get ownership for new commitments
+            var k = nExisting; // offset for new commitments
+            for (var i = 0; i < nNew; i++) {
+
+                var out[5] = HandleNewAssetCommitment(
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                privateKey[k],
+                                nonce[k],
+                                newSaltPublicKey[i],
+                                newCiphertext[i]
+                            );
+                _newNullRootOut[k] <== out[0];
+                _newCommitmentRootOut[k] <== out[1];
+                _newCommitmentHashOut[k] <== out[2];
+                value[k] <== out[3];
+                asset[k] <== out[4];
+                k++;
+            }
+
+            // lastly ensure that value is conserved per asset:
+            // for the asset of every commitment & the external asset
+            // the sum of existing values (& externIO[0])
+            // equals the sum of new values (& externIO[1]).
+            // Any asset moved is held by at least one of them.
+            var nAssets = nExisting + nNew + 1;
+            signal checkedAsset[nAssets];
+            for (var a = 0; a < nExisting + nNew; a++) {
+                checkedAsset[a] <== asset[a];
+            }
+            checkedAsset[nAssets-1] <== externAsset;
+
+            signal totalEx[nAssets][nExisting+1];
+            signal totalNew[nAssets][nNew+1];
+            for (var a = 0; a < nAssets; a++) {
+                var isExternAsset = IsEqual()([externAsset, checkedAsset[a]]);
+                totalEx[a][0] <== externIO[0] * isExternAsset;
+                totalNew[a][0] <== externIO[1] * isExternAsset;
+
+                for (var i = 0; i < nExisting; i++) {
+                    var isAsset = IsEqual()([asset[i], checkedAsset[a]]);
+                    totalEx[a][i+1] <== totalEx[a][i] + value[i] * isAsset;
+                }
+                for (var i = 0; i < nNew; i++) {
+                    var isAsset = IsEqual()([asset[nExisting+i], checkedAsset[a]]);
+                    totalNew[a][i+1] <== totalNew[a][i] + value[nExisting+i] * isAsset;
+                }
+                totalEx[a][nExisting] === totalNew[a][nNew];
+            }
+
+            newNullRoot <== _newNullRootOut;
+            newCommitmentRoot <== _newCommitmentRootOut;
+            newCommitmentHash <== _newCommitmentHashOut;
+
+            // constraint on context
+            signal contextSqrd <== context * context;
+        }
+	`}
+
+	// PrivacyPoolWithRelayer is PrivacyPool for withdrawals through
+	// a relayer: the public fee is range checked & balanced
+	// against the commitments alongside externIO[1],
+	// relayer is bound to the proof like context
+	PrivacyPoolWithRelayer = Program{
+		Identity: "PrivacyPoolWithRelayer",
+		Src: `
+		template PrivacyPoolWithRelayer(maxTreeDepth, cipherLen, tupleLen, nExisting, nNew) {
+            /// **** Public Signals ****
+
+            // Scope is the domain identifier
+            // i.e. Keccak256(chainID, contractAddress)
+            input signal scope;
+            // The depth of the State Tree
+            // at which the merkleproofs
+            // were generated
+            input signal actualTreeDepth;
+
+            input signal context;
+            // external input values to existing commitments
+            // external output values from new commitments
+            input signal externIO[2];
+
+            // fee paid to the relayer out of the pool
+            // & the relayer address bound to the proof
+            input signal fee;
+            input signal relayer;
+
+            input signal existingStateRoot;
+            input signal newSaltPublicKey[nNew][2];
+            input signal newCiphertext[nNew][cipherLen];
+
+            /// **** End Of Public Signals ****
+
+            /// **** Private Signals ****
+
+            input signal privateKey[nExisting+nNew];
+            input signal nonce[nExisting+nNew];
+
+            input signal exSaltPublicKey[nExisting][2];
+            input signal exCiphertext[nExisting][cipherLen];
+            input signal exIndex[nExisting];
+            input signal exSiblings[nExisting][maxTreeDepth];
+
+            /// **** End Of Private Signals ****
+
+            output signal newNullRoot[nExisting+nNew];
+            output signal newCommitmentRoot[nExisting+nNew];
+            output signal newCommitmentHash[nExisting+nNew];
+
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
+            // ensure that External Input & Output
+            // fits within the 252 bits
+            var n2bIO[2][252];
+            n2bIO[0] = Num2Bits(252)(externIO[0]);
+            n2bIO[1] = Num2Bits(252)(externIO[1]);
+            var n2bFee[252] = Num2Bits(252)(fee);
+
+            signal _newNullRootOut[nNew+nExisting];
+            signal _newCommitmentRootOut[nNew+nExisting];
+            signal _newCommitmentHashOut[nNew+nExisting];
+
+            // value counted for every existing commitment
+            signal exValue[nExisting];
+
+            // get ownership & membership proofs for existing commitments
+            // and compute total sum
+            signal totalEx[nExisting+1];
+            totalEx[0] <== externIO[0];
+            for (var i = 0; i < nExisting; i++) {
+                var out[4] = HandleExistingCommitment(
+                                maxTreeDepth,
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                existingStateRoot,
+                                actualTreeDepth,
+                                privateKey[i],
+                                nonce[i],
+                                exSaltPublicKey[i],
+                                exCiphertext[i],
+                                exIndex[i],
+                                exSiblings[i]
+                            );
+                _newNullRootOut[i] <== out[0];
+                _newCommitmentRootOut[i] <== out[1];
+                _newCommitmentHashOut[i] <== out[2];
+                exValue[i] <== out[3];
+                totalEx[i+1] <== totalEx[i] + exValue[i];
+            }
+
+            // an existing commitment can't be spent twice
+            var exNullRoot[nExisting];
+            for (var i = 0; i < nExisting; i++) {
+                exNullRoot[i] = _newNullRootOut[i];
+            }
+            DistinctNullRoots(nExisting)(exNullRoot, exValue);
+
+            // get ownership for new commitments
+            // and compute total sum
+            signal totalNew[nNew+1];
+            // the fee is withdrawn alongside externIO[1]
+            totalNew[0] <== externIO[1] + fee;
+            var k = nExisting; // offset for new commitments
+            for (var i = 0; i < nNew; i++) {
+
+                var out[4] = HandleNewCommitment(
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                privateKey[k],
+                                nonce[k],
+                                newSaltPublicKey[i],
+                                newCiphertext[i]
+                            );
+                _newNullRootOut[k] <== out[0];
+                _newCommitmentRootOut[k] <== out[1];
+                _newCommitmentHashOut[k] <== out[2];
+                totalNew[i+1] <== totalNew[i] + out[3];
+                k++;
+            }
+
+            // lastly ensure that all total sums are equal
+            signal sumEqCheck <== IsEqual()(
+                                [
+                                    totalEx[nExisting],
+                                    totalNew[nNew]
+                                ]
+                            );
+            sumEqCheck === 1;
+
+            newNullRoot <== _newNullRootOut;
+            newCommitmentRoot <== _newCommitmentRootOut;
+            newCommitmentHash <== _newCommitmentHashOut;
+
+            // constraint on context & relayer
+            signal contextSqrd <== context * context;
+            signal relayerSqrd <== relayer * relayer;
+        }
+	`}
+
+	// PrivacyPoolDeposit is PrivacyPool without existing commitments:
+	// the externInput value is deposited into nNew new commitments.
+	// The state tree isn't involved so that
+	// actualTreeDepth & existingStateRoot aren't public signals.
+	PrivacyPoolDeposit = Program{
+		Identity: "PrivacyPoolDeposit",
+		Src: `
+		template PrivacyPoolDeposit(cipherLen, tupleLen, nNew) {
+            assert(nNew > 0);
+
+            /// **** Public Signals ****
+
+            // Scope is the domain identifier
+            // i.e. Keccak256(chainID, contractAddress)
+            input signal scope;
+            input signal context;
+            // external input value to the new commitments
+            input signal externInput;
+
+            input signal newSaltPublicKey[nNew][2];
+            input signal newCiphertext[nNew][cipherLen];
+
+            /// **** End Of Public Signals ****
+
+            /// **** Private Signals ****
+
+            input signal privateKey[nNew];
+            input signal nonce[nNew];
+
+            /// **** End Of Private Signals ****
+
+            output signal newNullRoot[nNew];
+            output signal newCommitmentRoot[nNew];
+            output signal newCommitmentHash[nNew];
+
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
+            // ensure that External Input
+            // fits within the 252 bits
+            var n2bInput[252] = Num2Bits(252)(externInput);
+
+            // get ownership for new commitments
+            // and compute total sum
+            signal totalNew[nNew+1];
+            totalNew[0] <== 0;
+            for (var i = 0; i < nNew; i++) {
+                var out[4] = HandleNewCommitment(
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                privateKey[i],
+                                nonce[i],
+                                newSaltPublicKey[i],
+                                newCiphertext[i]
+                            );
+                newNullRoot[i] <== out[0];
+                newCommitmentRoot[i] <== out[1];
+                newCommitmentHash[i] <== out[2];
+                totalNew[i+1] <== totalNew[i] + out[3];
+            }
+
+            // lastly ensure that the deposit is fully committed
+            signal sumEqCheck <== IsEqual()([externInput, totalNew[nNew]]);
+            sumEqCheck === 1;
+
+            // constraint on context
+            signal contextSqrd <== context * context;
+        }
+	`}
+
+	// PrivacyPoolWithdraw is PrivacyPool without new commitments:
+	// the nExisting existing commitments are withdrawn
+	// in full as the externOutput value.
+	// No new commitment is published so that
+	// newSaltPublicKey & newCiphertext aren't public signals.
+	PrivacyPoolWithdraw = Program{
+		Identity: "PrivacyPoolWithdraw",
+		Src: `
+		template PrivacyPoolWithdraw(maxTreeDepth, cipherLen, tupleLen, nExisting) {
+            assert(nExisting > 0);
+
+            /// **** Public Signals ****
+
+            // Scope is the domain identifier
+            // i.e. Keccak256(chainID, contractAddress)
+            input signal scope;
+            // The depth of the State Tree
+            // at which the merkleproofs
+            // were generated
+            input signal actualTreeDepth;
+
+            input signal context;
+            // external output value from the existing commitments
+            input signal externOutput;
+
+            input signal existingStateRoot;
+
+            /// **** End Of Public Signals ****
+
+            /// **** Private Signals ****
+
+            input signal privateKey[nExisting];
+            input signal nonce[nExisting];
+
+            input signal exSaltPublicKey[nExisting][2];
+            input signal exCiphertext[nExisting][cipherLen];
+            input signal exIndex[nExisting];
+            input signal exSiblings[nExisting][maxTreeDepth];
+
+            /// **** End Of Private Signals ****
+
+            output signal newNullRoot[nExisting];
+            output signal newCommitmentRoot[nExisting];
+            output signal newCommitmentHash[nExisting];
+
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
+            // ensure that External Output
+            // fits within the 252 bits
+            var n2bOutput[252] = Num2Bits(252)(externOutput);
+
+            // value counted for every existing commitment
+            signal exValue[nExisting];
+
+            // get ownership & membership proofs for existing commitments
+            // and compute total sum
+            signal totalEx[nExisting+1];
+            totalEx[0] <== 0;
+            for (var i = 0; i < nExisting; i++) {
+                var out[4] = HandleExistingCommitment(
+                                maxTreeDepth,
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                existingStateRoot,
+                                actualTreeDepth,
+                                privateKey[i],
+                                nonce[i],
+                                exSaltPublicKey[i],
+                                exCiphertext[i],
+                                exIndex[i],
+                                exSiblings[i]
+                            );
+                newNullRoot[i] <== out[0];
+                newCommitmentRoot[i] <== out[1];
+                newCommitmentHash[i] <== out[2];
+                exValue[i] <== out[3];
+                totalEx[i+1] <== totalEx[i] + exValue[i];
+            }
+
+            // an existing commitment can't be spent twice
+            DistinctNullRoots(nExisting)(newNullRoot, exValue);
+
+            // lastly ensure that the existing commitments are fully withdrawn
+            signal sumEqCheck <== IsEqual()([totalEx[nExisting], externOutput]);
+            sumEqCheck === 1;
+
+            // constraint on context
+            signal contextSqrd <== context * context;
+        }
+	`}
+
+	// PrivacyPoolMerge consolidates nExisting existing commitments
+	// into a single new commitment of their total value.
+	// Every membership proof is checked against the same existingStateRoot,
+	// no value enters or leaves the pool so that there is no externIO.
+	// Outputs are laid out as in PrivacyPool (existing then new).
+	PrivacyPoolMerge = Program{
+		Identity: "PrivacyPoolMerge",
+		Src: `
+		template PrivacyPoolMerge(maxTreeDepth, cipherLen, tupleLen, nExisting) {
+            assert(nExisting > 1);
+
+            /// **** Public Signals ****
+
+            // Scope is the domain identifier
+            // i.e. Keccak256(chainID, contractAddress)
+            input signal scope;
+            // The depth of the State Tree
+            // at which the merkleproofs
+            // were generated
+            input signal actualTreeDepth;
+
+            input signal context;
+
+            input signal existingStateRoot;
+            input signal newSaltPublicKey[2];
+            input signal newCiphertext[cipherLen];
+
+            /// **** End Of Public Signals ****
+
+            /// **** Private Signals ****
+
+            input signal privateKey[nExisting+1];
+            input signal nonce[nExisting+1];
+
+            input signal exSaltPublicKey[nExisting][2];
+            input signal exCiphertext[nExisting][cipherLen];
+            input signal exIndex[nExisting];
+            input signal exSiblings[nExisting][maxTreeDepth];
+
+            /// **** End Of Private Signals ****
+
+            output signal newNullRoot[nExisting+1];
+            output signal newCommitmentRoot[nExisting+1];
+            output signal newCommitmentHash[nExisting+1];
+
+            // bit width of commitment values
+            var valueBits = VALUE_BITS();
+            // unchecked decryption (see DecryptCommitment),
+            // invalid ciphertexts invalidate their commitment
+            var checked = 0;
+
+            // value counted for every existing commitment
+            signal exValue[nExisting];
+            signal exNullRoot[nExisting];
+
+            // get ownership & membership proofs for existing commitments
+            // and compute total sum
+            signal totalEx[nExisting+1];
+            totalEx[0] <== 0;
+            for (var i = 0; i < nExisting; i++) {
+                var out[4] = HandleExistingCommitment(
+                                maxTreeDepth,
+                                cipherLen,
+                                tupleLen,
+                                valueBits,
+                                checked
+                            )(
+                                scope,
+                                existingStateRoot,
+                                actualTreeDepth,
+                                privateKey[i],
+                                nonce[i],
+                                exSaltPublicKey[i],
+                                exCiphertext[i],
+                                exIndex[i],
+                                exSiblings[i]
+                            );
+                exNullRoot[i] <== out[0];
+                newNullRoot[i] <== out[0];
+                newCommitmentRoot[i] <== out[1];
+                newCommitmentHash[i] <== out[2];
+                exValue[i] <== out[3];
+                totalEx[i+1] <== totalEx[i] + exValue[i];
+            }
+
+            // an existing commitment can't be spent twice
+            DistinctNullRoots(nExisting)(exNullRoot, exValue);
+
+            // get ownership for the merged commitment
+            var out[4] = HandleNewCommitment(
+                            cipherLen,
+                            tupleLen,
+                            valueBits,
+                            checked
+                        )(
+                            scope,
+                            privateKey[nExisting],
+                            nonce[nExisting],
+                            newSaltPublicKey,
+                            newCiphertext
+                        );
+            newNullRoot[nExisting] <== out[0];
+            newCommitmentRoot[nExisting] <== out[1];
+            newCommitmentHash[nExisting] <== out[2];
+
+            // lastly ensure that the merged commitment holds the total value
+            signal sumEqCheck <== IsEqual()([totalEx[nExisting], out[3]]);
+            sumEqCheck === 1;
+
+            // constraint on context
+            signal contextSqrd <== context * context;
+        }
+	`}
 )
@@ -1,18 +1,28 @@
 package privacypool
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
 	"github.com/0xBow-io/privacy-pool-veritas/common/bit"
 	"github.com/0xBow-io/privacy-pool-veritas/common/comparators"
 	"github.com/0xBow-io/privacy-pool-veritas/common/ecdh"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
 	"github.com/0xBow-io/privacy-pool-veritas/common/logic"
 	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
 	"github.com/0xBow-io/privacy-pool-veritas/common/multiplexer"
 	"github.com/0xBow-io/privacy-pool-veritas/common/poseidon"
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
 	"github.com/0xBow-io/privacy-pool-veritas/common/scalar"
 	"github.com/0xBow-io/privacy-pool-veritas/common/utils"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+	"github.com/0xBow-io/privacy-pool-veritas/export"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
 	. "github.com/0xBow-io/veritas"
 	"github.com/test-go/testify/require"
 )
@@ -60,3 +70,385 @@ func Test_Compile_PrivacyPool(t *testing.T) {
 	}
 
 }
+
+// associationInputs extends the input JSON of PrivacyPool with the
+// association set membership proofs of existing commitments.
+// Existing commitments absent from the association tree
+// are given an empty proof.
+func associationInputs(t *testing.T, inputs *PrivacyPoolInputs, tree *merkletree.LeanIMT, maxDepth int, existing ...*core.Commitment) []byte {
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+
+	var raw map[string]interface{}
+	require.Nil(t, json.Unmarshal(data, &raw))
+
+	var (
+		indexes  = make([]*big.Int, len(existing))
+		siblings = make([]*big.Int, 0, len(existing)*maxDepth)
+	)
+	for i, c := range existing {
+		indexes[i] = big.NewInt(0)
+		proof := &merkletree.LeanIMTProof{Siblings: make([]*big.Int, maxDepth)}
+		for j := range proof.Siblings {
+			proof.Siblings[j] = big.NewInt(0)
+		}
+		if index := tree.IndexOf(c.CommitmentRoot); index >= 0 {
+			proof, err = tree.GenerateProof(index, maxDepth)
+			require.Nil(t, err)
+			indexes[i] = big.NewInt(int64(proof.LeafIndex))
+		}
+		siblings = append(siblings, proof.Siblings...)
+	}
+	raw["associationRoot"] = tree.Root().String()
+	raw["actualAssociationTreeDepth"] = tree.Depth()
+	raw["exAssociationIndex"] = toDecimals(indexes...)
+	raw["exAssociationSiblings"] = toDecimals(siblings...)
+
+	data, err = json.Marshal(raw)
+	require.Nil(t, err)
+	return data
+}
+
+func Test_PrivacyPoolWithAssociation(t *testing.T) {
+	const maxAssociationDepth = 3
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, associationRoot, actualAssociationTreeDepth, newSaltPublicKey, newCiphertext]} = PrivacyPoolWithAssociation(4, 3, 7, 4, 2, 2);")
+	defer lib.Burn()
+
+	evaluate := func(data []byte) Evaluation {
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		return evaluation
+	}
+
+	builder, existing, _ := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+
+	// all existing commitments are associated
+	approved := newTestStateTree(t, existing...)
+	evaluation := evaluate(associationInputs(t, inputs, approved, maxAssociationDepth, existing...))
+	require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+	// an existing commitment is not part of the association set
+	approved = newTestStateTree(t, existing[0])
+	evaluation = evaluate(associationInputs(t, inputs, approved, maxAssociationDepth, existing...))
+	require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()))
+
+	// inclusion proven against a different association set
+	other := newTestStateTree(t, existing...)
+	data := associationInputs(t, inputs, other, maxAssociationDepth, existing...)
+	var raw map[string]interface{}
+	require.Nil(t, json.Unmarshal(data, &raw))
+	raw["associationRoot"] = approved.Root().String()
+	data, err = json.Marshal(raw)
+	require.Nil(t, err)
+	evaluation = evaluate(data)
+	require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()))
+
+	// void commitments need not be associated
+	builder, existing, _ = newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 0}, []int64{70, 0})
+	inputs, err = builder.Build()
+	require.Nil(t, err)
+	approved = newTestStateTree(t, existing[0])
+	evaluation = evaluate(associationInputs(t, inputs, approved, maxAssociationDepth, existing...))
+	require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+}
+
+func Test_Resolve(t *testing.T) {
+	// every registered pkg declares all of its dependencies
+	for _, name := range registry.Names() {
+		pkgs, err := Resolve(name)
+		require.Nil(t, err, name)
+		require.NotEmpty(t, pkgs)
+	}
+
+	pkgs, err := Bundle()
+	require.Nil(t, err)
+	require.Len(t, pkgs, len(registry.Names()))
+
+	_, err = Resolve("bit.UnknownCircuitPkg")
+	require.True(t, errors.Is(err, registry.ErrUnknownPkg))
+}
+
+// The exported circom source compiles
+// and evaluates just like the circuit pkgs
+func Test_Export_Circom(t *testing.T) {
+	var (
+		main = Program{
+			Identity: "main",
+			Src:      "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, newSaltPublicKey, newCiphertext]} = " + testParams.Instance() + ";",
+		}
+		src bytes.Buffer
+	)
+	pkgs, err := Bundle()
+	require.Nil(t, err)
+	require.Nil(t, export.Circom(&src, main, pkgs...))
+
+	lib := compilePrivacyPool(t, main.Src)
+	defer lib.Burn()
+
+	exported := NewEmptyLibrary()
+	defer exported.Burn()
+	reports, err := exported.Compile(CircuitPkg{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs:      []Program{{Identity: "privacypool.circom", Src: src.String()}},
+	})
+	require.Nil(t, err)
+	require.False(t, harness.HasErrors(reports), reports.String())
+
+	builder, _, _ := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+
+	expected, err := lib.Evaluate(data)
+	require.Nil(t, err)
+	actual, err := exported.Evaluate(data)
+	require.Nil(t, err)
+
+	// anonymous components are named after their source offset
+	// so only the number of symbols is compared
+	require.Len(t, actual.UnSatisfiedConstraints(), 0)
+	require.Equal(t, len(expected.ConstrainedSyms()), len(actual.ConstrainedSyms()))
+	require.Equal(t, len(expected.SatisfiedConstraints()), len(actual.SatisfiedConstraints()))
+	require.Equal(t, expected.WitnessAssignment(), actual.WitnessAssignment())
+}
+
+// assetInputs spends existing asset tagged commitments
+// into new ones, externIO being denominated in externAsset
+func assetInputs(t *testing.T, params PrivacyPoolParams, externAsset int64, externIO [2]int64, existing, created [][2]int64) []byte {
+	var (
+		scope  = randomBelow(t, field.Modulus)
+		commit = func(v [2]int64) *core.Commitment {
+			c, err := core.NewAssetCommitment(
+				scope,
+				big.NewInt(v[0]),
+				big.NewInt(v[1]),
+				randomBelow(t, babyjub.SubOrder),
+				randomBelow(t, babyjub.SubOrder),
+				randomBelow(t, new(big.Int).Lsh(big.NewInt(1), 128)),
+			)
+			require.Nil(t, err)
+			return c
+		}
+		exCommitments = make([]*core.Commitment, len(existing))
+	)
+	for i, v := range existing {
+		exCommitments[i] = commit(v)
+	}
+
+	tree := newTestStateTree(t, exCommitments...)
+	builder := NewPrivacyPoolInputsBuilder(params).
+		Scope(scope).
+		Context(randomBelow(t, field.Modulus)).
+		ExternIO(big.NewInt(externIO[0]), big.NewInt(externIO[1])).
+		ExternAsset(big.NewInt(externAsset)).
+		StateTree(tree.Root(), tree.Depth())
+	for _, c := range exCommitments {
+		proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), params.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(c, proof)
+	}
+	for _, v := range created {
+		builder.AddNewCommitment(commit(v))
+	}
+	inputs, err := builder.BuildWithAssets()
+	require.Nil(t, err)
+
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+	return data
+}
+
+func Test_PrivacyPoolWithAssets(t *testing.T) {
+	params := PrivacyPoolParams{MaxTreeDepth: 4, CipherLen: 7, TupleLen: 5, NExisting: 2, NNew: 2}
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, externAsset, existingStateRoot, newSaltPublicKey, newCiphertext]} = PrivacyPoolWithAssets(4, 7, 5, 2, 2);")
+	defer lib.Burn()
+
+	const assetA, assetB = 1, 2
+	for _, tc := range []struct {
+		name        string
+		externAsset int64
+		externIO    [2]int64
+		existing    [][2]int64 // (assetId, value)
+		created     [][2]int64
+		valid       bool
+	}{
+		{"withdraw", assetA, [2]int64{0, 30}, [][2]int64{{assetA, 100}, {assetB, 50}}, [][2]int64{{assetA, 70}, {assetB, 50}}, true},
+		{"deposit", assetB, [2]int64{25, 0}, [][2]int64{{assetA, 100}, {assetB, 0}}, [][2]int64{{assetA, 100}, {assetB, 25}}, true},
+		{"merge", assetA, [2]int64{0, 0}, [][2]int64{{assetA, 100}, {assetA, 50}}, [][2]int64{{assetA, 150}, {assetB, 0}}, true},
+		// total value is conserved but not per asset
+		{"swap", assetA, [2]int64{0, 0}, [][2]int64{{assetA, 100}, {assetB, 50}}, [][2]int64{{assetA, 50}, {assetB, 100}}, false},
+		// withdrawal in another asset than the one spent
+		{"extern asset", assetB, [2]int64{0, 30}, [][2]int64{{assetA, 100}, {assetB, 50}}, [][2]int64{{assetA, 70}, {assetB, 50}}, false},
+		// new asset out of thin air
+		{"mint", assetA, [2]int64{0, 0}, [][2]int64{{assetA, 100}, {assetA, 0}}, [][2]int64{{assetA, 100}, {assetB, 10}}, false},
+	} {
+		evaluation, err := lib.Evaluate(assetInputs(t, params, tc.externAsset, tc.externIO, tc.existing, tc.created))
+		require.Nil(t, err)
+		if tc.valid {
+			require.Len(t, evaluation.UnSatisfiedConstraints(), 0, tc.name)
+		} else {
+			require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), tc.name)
+		}
+	}
+
+	// externAsset is required & commitments must be asset tagged
+	_, err := NewPrivacyPoolInputsBuilder(params).BuildWithAssets()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	require.Contains(t, err.Error(), "externAsset")
+	_, err = NewPrivacyPoolInputsBuilder(testParams).ExternAsset(big.NewInt(assetA)).BuildWithAssets()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+}
+
+// depositInputs encodes the input JSON of PrivacyPoolDeposit(7, 4, len(created))
+func depositInputs(t *testing.T, scope *big.Int, externInput int64, created ...*core.Commitment) []byte {
+	params := PrivacyPoolParams{CipherLen: 7, TupleLen: 4, NNew: len(created)}
+	builder := NewPrivacyPoolInputsBuilder(params).
+		Scope(scope).
+		Context(randomBelow(t, field.Modulus)).
+		ExternIO(big.NewInt(externInput), nil)
+	for _, c := range created {
+		builder.AddNewCommitment(c)
+	}
+	inputs, err := builder.BuildDeposit()
+	require.Nil(t, err)
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+	return data
+}
+
+func Test_PrivacyPoolDeposit(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, context, externInput, newSaltPublicKey, newCiphertext]} = PrivacyPoolDeposit(7, 4, 2);")
+	defer lib.Burn()
+
+	scope := randomBelow(t, field.Modulus)
+	for _, tc := range []struct {
+		name        string
+		externInput int64
+		created     []*core.Commitment
+		valid       bool
+	}{
+		{"deposit", 100, []*core.Commitment{newTestCommitment(t, scope, 60), newTestCommitment(t, scope, 40)}, true},
+		{"single", 100, []*core.Commitment{newTestCommitment(t, scope, 100), newTestCommitment(t, scope, 0)}, true},
+		{"under committed", 100, []*core.Commitment{newTestCommitment(t, scope, 60), newTestCommitment(t, scope, 30)}, false},
+		{"over committed", 100, []*core.Commitment{newTestCommitment(t, scope, 60), newTestCommitment(t, scope, 50)}, false},
+		// the value of a commitment under another scope is nulled
+		{"other scope", 100, []*core.Commitment{newTestCommitment(t, scope, 60), newTestCommitment(t, randomBelow(t, field.Modulus), 40)}, false},
+	} {
+		evaluation, err := lib.Evaluate(depositInputs(t, scope, tc.externInput, tc.created...))
+		require.Nil(t, err)
+		if !tc.valid {
+			require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), tc.name)
+			continue
+		}
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0, tc.name)
+		for i, c := range tc.created {
+			require.Equal(t, c.CommitmentRoot, harness.Signal(t, evaluation, fmt.Sprintf("main.newCommitmentRoot[%d]", i)), tc.name)
+			require.Equal(t, c.CommitmentHash, harness.Signal(t, evaluation, fmt.Sprintf("main.newCommitmentHash[%d]", i)), tc.name)
+			require.Equal(t, big.NewInt(0), harness.Signal(t, evaluation, fmt.Sprintf("main.newNullRoot[%d]", i)), tc.name)
+		}
+	}
+	// existing commitments, an externOutput & a state tree aren't deposited
+	params := PrivacyPoolParams{CipherLen: 7, TupleLen: 4, NNew: 2}
+	_, err := NewPrivacyPoolInputsBuilder(testParams).BuildDeposit()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	_, err = NewPrivacyPoolInputsBuilder(params).ExternIO(big.NewInt(1), big.NewInt(1)).BuildDeposit()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	_, err = NewPrivacyPoolInputsBuilder(params).StateTree(big.NewInt(1), 1).BuildDeposit()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+}
+
+// withdrawInputs builds the inputs of PrivacyPoolWithdraw(4, 7, 4, len(existing))
+// with the membership proofs of existing in tree
+func withdrawInputs(t *testing.T, scope *big.Int, tree *merkletree.LeanIMT, externOutput int64, existing ...*core.Commitment) *PrivacyPoolWithdrawInputs {
+	params := PrivacyPoolParams{MaxTreeDepth: 4, CipherLen: 7, TupleLen: 4, NExisting: len(existing)}
+	builder := NewPrivacyPoolInputsBuilder(params).
+		Scope(scope).
+		Context(randomBelow(t, field.Modulus)).
+		ExternIO(nil, big.NewInt(externOutput)).
+		StateTree(tree.Root(), tree.Depth())
+	for _, c := range existing {
+		index := tree.IndexOf(c.CommitmentRoot)
+		if index < 0 {
+			// not a member, prove against any leaf
+			index = 0
+		}
+		proof, err := tree.GenerateProof(index, params.MaxTreeDepth)
+		require.Nil(t, err)
+		// unlike AddExistingCommitment, the proof leaf isn't checked
+		builder.AddExisting(
+			c.PrivateKey, c.Nonce,
+			[2]*big.Int{c.SaltPublicKey.X, c.SaltPublicKey.Y},
+			c.Ciphertext,
+			big.NewInt(int64(proof.LeafIndex)),
+			proof.Siblings,
+		)
+	}
+	inputs, err := builder.BuildWithdraw()
+	require.Nil(t, err)
+	return inputs
+}
+
+func Test_PrivacyPoolWithdraw(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externOutput, existingStateRoot]} = PrivacyPoolWithdraw(4, 7, 4, 2);")
+	defer lib.Burn()
+
+	var (
+		scope = randomBelow(t, field.Modulus)
+		a     = newTestCommitment(t, scope, 100)
+		b     = newTestCommitment(t, scope, 50)
+		void  = newTestCommitment(t, scope, 0)
+		tree  = newTestStateTree(t, a, b)
+	)
+	for _, tc := range []struct {
+		name         string
+		externOutput int64
+		existing     []*core.Commitment
+		valid        bool
+	}{
+		{"withdraw", 150, []*core.Commitment{a, b}, true},
+		// void commitments don't have to be in the state tree
+		{"single", 50, []*core.Commitment{b, void}, true},
+		{"partial", 140, []*core.Commitment{a, b}, false},
+		{"over withdrawn", 160, []*core.Commitment{a, b}, false},
+		{"not a member", 150, []*core.Commitment{a, newTestCommitment(t, scope, 50)}, false},
+	} {
+		data, err := json.Marshal(withdrawInputs(t, scope, tree, tc.externOutput, tc.existing...))
+		require.Nil(t, err)
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		if !tc.valid {
+			require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()), tc.name)
+			continue
+		}
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0, tc.name)
+		for i, c := range tc.existing {
+			require.Equal(t, c.NullRoot, harness.Signal(t, evaluation, fmt.Sprintf("main.newNullRoot[%d]", i)), tc.name)
+			require.Equal(t, big.NewInt(0), harness.Signal(t, evaluation, fmt.Sprintf("main.newCommitmentRoot[%d]", i)), tc.name)
+		}
+	}
+	// spent twice, once b is swapped for a
+	params := PrivacyPoolParams{MaxTreeDepth: 4, CipherLen: 7, TupleLen: 4, NExisting: 2}
+	inputs := withdrawInputs(t, scope, tree, 200, a, b)
+	inputs.PrivateKey[1] = inputs.PrivateKey[0]
+	inputs.Nonce[1] = inputs.Nonce[0]
+	inputs.ExSaltPublicKey[1] = inputs.ExSaltPublicKey[0]
+	inputs.ExCiphertext[1] = inputs.ExCiphertext[0]
+	inputs.ExIndex[1] = inputs.ExIndex[0]
+	inputs.ExSiblings[1] = inputs.ExSiblings[0]
+	require.True(t, errors.Is(inputs.CheckDuplicates(params), ErrDuplicateCommitment))
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+	evaluation, err := lib.Evaluate(data)
+	require.Nil(t, err)
+	require.NotEqual(t, 0, len(evaluation.UnSatisfiedConstraints()))
+
+	// new commitments & an externInput aren't withdrawn
+	_, err = NewPrivacyPoolInputsBuilder(testParams).BuildWithdraw()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+	_, err = NewPrivacyPoolInputsBuilder(params).ExternIO(big.NewInt(1), big.NewInt(0)).BuildWithdraw()
+	require.True(t, errors.Is(err, ErrInvalidInputs))
+}
@@ -0,0 +1,208 @@
+package privacypool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+)
+
+var ErrInvalidPublicSignals = errors.New("invalid PrivacyPool public signals")
+
+// PublicInputs are the public input signals of PrivacyPool
+// in declaration order, i.e.
+//
+//	component main {public[scope, actualTreeDepth, ...]} = PrivacyPool(...);
+var PublicInputs = []string{
+	"scope",
+	"actualTreeDepth",
+	"context",
+	"externIO",
+	"existingStateRoot",
+	"newSaltPublicKey",
+	"newCiphertext",
+}
+
+// SignalLayout locates a signal in the public signal vector.
+// Multi-dimensional signals are flattened in row-major order
+// i.e. newCiphertext[i][j] is at Index + i*cipherLen + j
+type SignalLayout struct {
+	Name   string `json:"name"`
+	Index  int    `json:"index"`
+	Shape  []int  `json:"shape"`
+	Output bool   `json:"output"`
+	// Solidity ABI type i.e. uint256[7][2] for newCiphertext[2][7]
+	Type string `json:"type"`
+}
+
+// Size is the number of elements of the signal
+func (s SignalLayout) Size() int {
+	size := 1
+	for _, d := range s.Shape {
+		size *= d
+	}
+	return size
+}
+
+// PublicSignalLayout returns the layout of the public signal vector
+// of PrivacyPool for params: outputs then PublicInputs, both in
+// declaration order (the order circom assigns them in the witness)
+func (p PrivacyPoolParams) PublicSignalLayout() []SignalLayout {
+	var (
+		nTotal = p.NExisting + p.NNew
+		layout []SignalLayout
+		index  int
+		add    = func(name string, output bool, shape ...int) {
+			s := SignalLayout{Name: name, Index: index, Shape: shape, Output: output}
+			if s.Shape == nil {
+				s.Shape = []int{}
+			}
+			s.Type = abiType(s.Shape)
+			layout = append(layout, s)
+			index += s.Size()
+		}
+	)
+	add("newNullRoot", true, nTotal)
+	add("newCommitmentRoot", true, nTotal)
+	add("newCommitmentHash", true, nTotal)
+	add("scope", false)
+	add("actualTreeDepth", false)
+	add("context", false)
+	add("externIO", false, 2)
+	add("existingStateRoot", false)
+	add("newSaltPublicKey", false, p.NNew, 2)
+	add("newCiphertext", false, p.NNew, p.CipherLen)
+	return layout
+}
+
+// PublicSignalsLength is the length of the public signal vector
+func (p PrivacyPoolParams) PublicSignalsLength() int {
+	layout := p.PublicSignalLayout()
+	last := layout[len(layout)-1]
+	return last.Index + last.Size()
+}
+
+// PrivacyPoolPublicSignals holds the public signals
+// (outputs & public inputs) of a PrivacyPool proof
+type PrivacyPoolPublicSignals struct {
+	PrivacyPoolOutputs
+
+	Scope             *big.Int
+	ActualTreeDepth   *big.Int
+	Context           *big.Int
+	ExternIO          [2]*big.Int
+	ExistingStateRoot *big.Int
+	NewSaltPublicKey  [][2]*big.Int
+	NewCiphertext     [][]*big.Int
+}
+
+// PublicSignals returns the public signals
+// of a proof of the inputs with outputs
+func (in *PrivacyPoolInputs) PublicSignals(outputs *PrivacyPoolOutputs) *PrivacyPoolPublicSignals {
+	return &PrivacyPoolPublicSignals{
+		PrivacyPoolOutputs: *outputs,
+		Scope:              in.Scope,
+		ActualTreeDepth:    in.ActualTreeDepth,
+		Context:            in.Context,
+		ExternIO:           in.ExternIO,
+		ExistingStateRoot:  in.ExistingStateRoot,
+		NewSaltPublicKey:   in.NewSaltPublicKey,
+		NewCiphertext:      in.NewCiphertext,
+	}
+}
+
+// Encode flattens the public signals into
+// the public signal vector laid out for params
+func (s *PrivacyPoolPublicSignals) Encode(params PrivacyPoolParams) ([]*big.Int, error) {
+	var (
+		out    = make([]*big.Int, 0, params.PublicSignalsLength())
+		errs   []error
+		values = s.values()
+	)
+	for i, c := range s.NewCiphertext {
+		if len(c) != params.CipherLen {
+			errs = append(errs, fmt.Errorf("newCiphertext[%d]: expected %d elements, got %d", i, params.CipherLen, len(c)))
+		}
+	}
+	for _, signal := range params.PublicSignalLayout() {
+		v := values[signal.Name]
+		if len(v) != signal.Size() {
+			errs = append(errs, fmt.Errorf("%s: expected %d elements, got %d", signal.Name, signal.Size(), len(v)))
+			continue
+		}
+		for i, x := range v {
+			if !field.IsCanonical(x) {
+				errs = append(errs, fmt.Errorf("%s[%d]: not a field element", signal.Name, i))
+			}
+		}
+		out = append(out, v...)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w for %s: %w", ErrInvalidPublicSignals, params.Instance(), errors.Join(errs...))
+	}
+	return out, nil
+}
+
+// DecodePublicSignals parses a public signal vector laid out for params
+func DecodePublicSignals(params PrivacyPoolParams, signals []*big.Int) (*PrivacyPoolPublicSignals, error) {
+	if expected := params.PublicSignalsLength(); len(signals) != expected {
+		return nil, fmt.Errorf("%w for %s: expected %d elements, got %d",
+			ErrInvalidPublicSignals, params.Instance(), expected, len(signals))
+	}
+	for i, x := range signals {
+		if !field.IsCanonical(x) {
+			return nil, fmt.Errorf("%w: element %d is not a field element", ErrInvalidPublicSignals, i)
+		}
+	}
+
+	var (
+		s   PrivacyPoolPublicSignals
+		get = make(map[string][]*big.Int)
+	)
+	for _, signal := range params.PublicSignalLayout() {
+		get[signal.Name] = append([]*big.Int{}, signals[signal.Index:signal.Index+signal.Size()]...)
+	}
+	s.NewNullRoot = get["newNullRoot"]
+	s.NewCommitmentRoot = get["newCommitmentRoot"]
+	s.NewCommitmentHash = get["newCommitmentHash"]
+	s.Scope = get["scope"][0]
+	s.ActualTreeDepth = get["actualTreeDepth"][0]
+	s.Context = get["context"][0]
+	s.ExternIO = [2]*big.Int{get["externIO"][0], get["externIO"][1]}
+	s.ExistingStateRoot = get["existingStateRoot"][0]
+	s.NewSaltPublicKey = toPairs(get["newSaltPublicKey"])
+	s.NewCiphertext = toMatrix(get["newCiphertext"], params.CipherLen)
+	return &s, nil
+}
+
+// Verify checks the outputs against the public inputs (see VerifyOutputs)
+func (s *PrivacyPoolPublicSignals) Verify(params PrivacyPoolParams) error {
+	return VerifyOutputs(params, &PrivacyPoolInputs{NewCiphertext: s.NewCiphertext}, &s.PrivacyPoolOutputs)
+}
+
+// values maps every public signal to its flattened elements
+func (s *PrivacyPoolPublicSignals) values() map[string][]*big.Int {
+	return map[string][]*big.Int{
+		"newNullRoot":       s.NewNullRoot,
+		"newCommitmentRoot": s.NewCommitmentRoot,
+		"newCommitmentHash": s.NewCommitmentHash,
+		"scope":             {s.Scope},
+		"actualTreeDepth":   {s.ActualTreeDepth},
+		"context":           {s.Context},
+		"externIO":          s.ExternIO[:],
+		"existingStateRoot": {s.ExistingStateRoot},
+		"newSaltPublicKey":  flattenPairs(s.NewSaltPublicKey),
+		"newCiphertext":     flattenMatrix(s.NewCiphertext),
+	}
+}
+
+// abiType returns the Solidity type of a signal of shape,
+// Solidity array dimensions are written innermost first
+func abiType(shape []int) string {
+	t := "uint256"
+	for i := len(shape) - 1; i >= 0; i-- {
+		t += fmt.Sprintf("[%d]", shape[i])
+	}
+	return t
+}
@@ -0,0 +1,77 @@
+package privacypool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/test-go/testify/require"
+)
+
+func Test_PublicSignalLayout(t *testing.T) {
+	params := PrivacyPoolParams{MaxTreeDepth: 32, CipherLen: 10, TupleLen: 7, NExisting: 3, NNew: 1}
+	layout := params.PublicSignalLayout()
+	require.Len(t, layout, 3+len(PublicInputs))
+	for i, name := range PublicInputs {
+		require.Equal(t, name, layout[3+i].Name)
+		require.False(t, layout[3+i].Output)
+	}
+	require.Equal(t, SignalLayout{Name: "newCommitmentHash", Index: 8, Shape: []int{4}, Output: true, Type: "uint256[4]"}, layout[2])
+	require.Equal(t, SignalLayout{Name: "scope", Index: 12, Shape: []int{}, Type: "uint256"}, layout[3])
+	require.Equal(t, SignalLayout{Name: "newCiphertext", Index: 20, Shape: []int{1, 10}, Type: "uint256[10][1]"}, layout[9])
+	require.Equal(t, 30, params.PublicSignalsLength())
+}
+
+// The layout matches the witness assignment of the compiled circuit
+func Test_PublicSignals(t *testing.T) {
+	lib := compilePrivacyPool(t, fmt.Sprintf("component main {public[%s]} = %s;", strings.Join(PublicInputs, ", "), testParams.Instance()))
+	defer lib.Burn()
+
+	builder, _, _ := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+	data, err := json.Marshal(inputs)
+	require.Nil(t, err)
+	evaluation, err := lib.Evaluate(data)
+	require.Nil(t, err)
+	require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+
+	var (
+		n         = testParams.PublicSignalsLength()
+		syms      = evaluation.ConstrainedSyms()
+		witnesses = evaluation.WitnessAssignment()
+		// witness 0 is the constant "one"
+		vector = witnesses[1 : n+1]
+	)
+	for _, signal := range testParams.PublicSignalLayout() {
+		first := "main." + signal.Name
+		for range signal.Shape {
+			first += "[0]"
+		}
+		require.Equal(t, first, syms[signal.Index], signal.Name)
+	}
+
+	decoded, err := DecodePublicSignals(testParams, vector)
+	require.Nil(t, err)
+	require.Equal(t, inputs.PublicSignals(evaluationOutputs(t, evaluation, testParams)), decoded)
+	require.Nil(t, decoded.Verify(testParams))
+
+	encoded, err := decoded.Encode(testParams)
+	require.Nil(t, err)
+	require.Equal(t, vector, encoded)
+
+	_, err = DecodePublicSignals(testParams, vector[1:])
+	require.True(t, errors.Is(err, ErrInvalidPublicSignals))
+
+	decoded.NewCiphertext[1] = decoded.NewCiphertext[1][:3]
+	_, err = decoded.Encode(testParams)
+	require.True(t, errors.Is(err, ErrInvalidPublicSignals))
+	require.Contains(t, err.Error(), "newCiphertext[1]: expected 7 elements, got 3")
+
+	decoded.NewCommitmentRoot[0] = new(big.Int).Neg(big.NewInt(1))
+	_, err = decoded.Encode(testParams)
+	require.Contains(t, err.Error(), "newCommitmentRoot[0]: not a field element")
+}
@@ -0,0 +1,89 @@
+package privacypool
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"golang.org/x/crypto/sha3"
+)
+
+// AddressLength is the length of a contract address
+const AddressLength = 20
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+// maxChainID is the exclusive upper bound of a uint256 chain ID
+var maxChainID = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// ComputeScope computes the scope signal of the pool deployed
+// at contract on chainID, reduced into the bn128 scalar field,
+// i.e. in Solidity:
+//
+//	uint256(keccak256(abi.encodePacked(block.chainid, address(this)))) % SNARK_SCALAR_FIELD
+//
+// the chain ID is encoded as a 32 bytes big endian uint256
+// followed by the 20 bytes of the address
+func ComputeScope(chainID *big.Int, contract [AddressLength]byte) (*big.Int, error) {
+	if chainID == nil || chainID.Sign() < 0 || chainID.Cmp(maxChainID) >= 0 {
+		return nil, fmt.Errorf("%w: chain ID must be a uint256", ErrInvalidScope)
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write(chainID.FillBytes(make([]byte, 32)))
+	h.Write(contract[:])
+	return field.Reduce(new(big.Int).SetBytes(h.Sum(nil))), nil
+}
+
+// ParseScope computes the scope of a chain ID
+// & contract address parsed with ParseChainID & ParseAddress
+func ParseScope(chainID, contract string) (*big.Int, error) {
+	id, err := ParseChainID(chainID)
+	if err != nil {
+		return nil, err
+	}
+	address, err := ParseAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeScope(id, address)
+}
+
+// ParseChainID parses a decimal or 0x-prefixed hexadecimal chain ID
+func ParseChainID(s string) (*big.Int, error) {
+	var (
+		id *big.Int
+		ok bool
+		in = strings.TrimSpace(s)
+	)
+	if strings.HasPrefix(in, "0x") || strings.HasPrefix(in, "0X") {
+		id, ok = new(big.Int).SetString(in[2:], 16)
+	} else {
+		id, ok = new(big.Int).SetString(in, 10)
+	}
+	if !ok || id.Sign() < 0 || id.Cmp(maxChainID) >= 0 {
+		return nil, fmt.Errorf("%w: %q is not a uint256 chain ID", ErrInvalidScope, s)
+	}
+	return id, nil
+}
+
+// ParseAddress parses a 20 bytes hexadecimal address,
+// the 0x prefix is optional & the case (checksum) is ignored
+func ParseAddress(s string) ([AddressLength]byte, error) {
+	var (
+		address [AddressLength]byte
+		in      = strings.TrimSpace(s)
+	)
+	if strings.HasPrefix(in, "0x") || strings.HasPrefix(in, "0X") {
+		in = in[2:]
+	}
+	if len(in) != 2*AddressLength {
+		return address, fmt.Errorf("%w: %q is not a %d bytes address", ErrInvalidScope, s, AddressLength)
+	}
+	if _, err := hex.Decode(address[:], []byte(in)); err != nil {
+		return address, fmt.Errorf("%w: %q is not a hexadecimal address", ErrInvalidScope, s)
+	}
+	return address, nil
+}
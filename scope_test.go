@@ -0,0 +1,63 @@
+package privacypool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/test-go/testify/require"
+)
+
+// Test_ComputeScope vectors were computed with the Keccak team's
+// CompactFIPS202 reference (Keccak[r=1088, c=512] with the 0x01 suffix)
+// over the 32 bytes chain ID || 20 bytes address, reduced mod p
+func Test_ComputeScope(t *testing.T) {
+	for _, v := range []struct {
+		chainID  string
+		contract string
+		scope    string
+	}{
+		{"1", "0x0000000000000000000000000000000000000000", "4510958009541845443739853631612735474753649951788867677224859638645313805773"},
+		{"1", "0xdAC17F958D2ee523a2206206994597C13D831ec7", "18158813743337674582394106812706835381810781011161792475190767068383925450308"},
+		{"11155111", "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984", "5073947013097279738290718587727924264484907191158679222491058535541827930542"},
+		{"0xa4b1", "ffffffffffffffffffffffffffffffffffffffff", "678118843409987393186689703813014289950120091512577562752518996368086087383"},
+	} {
+		scope, err := ParseScope(v.chainID, v.contract)
+		require.Nil(t, err)
+		require.Equal(t, v.scope, scope.String(), "%s %s", v.chainID, v.contract)
+		require.True(t, field.IsCanonical(scope))
+	}
+
+	// the address case is ignored
+	lower, err := ParseScope("1", "0xdac17f958d2ee523a2206206994597c13d831ec7")
+	require.Nil(t, err)
+	upper, err := ParseScope("0x1", "0XDAC17F958D2EE523A2206206994597C13D831EC7")
+	require.Nil(t, err)
+	require.Equal(t, lower, upper)
+}
+
+func Test_ParseScope_Errors(t *testing.T) {
+	var address = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+	for _, v := range []struct {
+		chainID  string
+		contract string
+	}{
+		{"", address},
+		{"-1", address},
+		{"0x", address},
+		{"0x1" + "0000000000000000000000000000000000000000000000000000000000000000", address},
+		{"one", address},
+		{"1", "0xdAC17F958D2ee523a2206206994597C13D831e"},
+		{"1", "0xdAC17F958D2ee523a2206206994597C13D831ec7ff"},
+		{"1", "0xgAC17F958D2ee523a2206206994597C13D831ec7"},
+	} {
+		_, err := ParseScope(v.chainID, v.contract)
+		require.True(t, errors.Is(err, ErrInvalidScope), "%q %q", v.chainID, v.contract)
+	}
+
+	_, err := ComputeScope(nil, [AddressLength]byte{})
+	require.True(t, errors.Is(err, ErrInvalidScope))
+	_, err = ComputeScope(new(big.Int).Lsh(big.NewInt(1), 256), [AddressLength]byte{})
+	require.True(t, errors.Is(err, ErrInvalidScope))
+}
@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrRegression = errors.New("circuit grew beyond its baseline")
+
+// Entry is the committed counts of a circuit
+type Entry struct {
+	Circuit
+	Counts
+}
+
+// Baseline is the list of circuits whose
+// counts are tracked for regressions
+type Baseline []Entry
+
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+func (b Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Compare errors if any of the actual counts exceeds the baseline
+func (e Entry) Compare(actual Counts) error {
+	var errs []error
+	for _, c := range []struct {
+		name             string
+		expected, actual int
+	}{
+		{"constraints", e.Constraints, actual.Constraints},
+		{"public inputs", e.PublicInputs, actual.PublicInputs},
+		{"private inputs", e.PrivateInputs, actual.PrivateInputs},
+		{"outputs", e.Outputs, actual.Outputs},
+		{"intermediates", e.Intermediates, actual.Intermediates},
+	} {
+		if c.actual > c.expected {
+			errs = append(errs, fmt.Errorf("%s: %d > %d (+%d)", c.name, c.actual, c.expected, c.actual-c.expected))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w %s: %w", ErrRegression, e.Circuit, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/registry"
+
+	. "github.com/0xBow-io/veritas"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found in circuit pkgs")
+	ErrCompile          = errors.New("failed to compile circuit")
+	ErrEvaluationLayout = errors.New("unsupported veritas evaluation layout")
+)
+
+var (
+	// input & output signal declarations
+	// i.e. "input signal a, b[n];" or "signal input a;"
+	reSignals = regexp.MustCompile(`\b(?:(input|output)\s+signal|signal\s+(input|output))\b([^;]*);`)
+	// anonymous components are named after their source position
+	// i.e. Num2Bits_43_1601
+	reAnonymous = regexp.MustCompile(`_\d+_\d+$`)
+	reIndexes   = regexp.MustCompile(`\[\d+\]`)
+)
+
+// Circuit is a template instantiation to measure
+type Circuit struct {
+	Template string   `json:"template"`
+	Params   []int    `json:"params"`
+	Public   []string `json:"public,omitempty"`
+}
+
+func (c Circuit) String() string {
+	params := make([]string, len(c.Params))
+	for i, p := range c.Params {
+		params[i] = strconv.Itoa(p)
+	}
+	return fmt.Sprintf("%s(%s)", c.Template, strings.Join(params, ", "))
+}
+
+// Main returns the main component instantiating the template
+func (c Circuit) Main() Program {
+	public := ""
+	if len(c.Public) > 0 {
+		public = fmt.Sprintf(" {public[%s]}", strings.Join(c.Public, ", "))
+	}
+	return Program{
+		Identity: "main",
+		Src:      fmt.Sprintf("component main%s = %s;", public, c),
+	}
+}
+
+// Counts are the number of constraints & signals of a circuit
+type Counts struct {
+	Constraints   int `json:"constraints"`
+	PublicInputs  int `json:"publicInputs"`
+	PrivateInputs int `json:"privateInputs"`
+	Outputs       int `json:"outputs"`
+	Intermediates int `json:"intermediates"`
+}
+
+// Component is the share of a sub-component
+// (all instances of it) in the circuit
+type Component struct {
+	Name        string `json:"name"`
+	Instances   int    `json:"instances"`
+	Constraints int    `json:"constraints"`
+	Signals     int    `json:"signals"`
+}
+
+// Measurement holds the counts of a circuit
+// broken down per sub-component
+type Measurement struct {
+	Circuit
+	Counts
+	Components []Component `json:"components,omitempty"`
+}
+
+// Measure compiles the circuit against pkgs
+// and counts its constraints & signals.
+//
+// Sub-components are named after their path relative to main
+// up to depth, with anonymous component positions
+// and array indexes stripped (i.e. "HandleExistingCommitment.Num2Bits").
+// A constraint is attributed to the smallest component
+// containing all of its signals.
+func Measure(c Circuit, depth int, pkgs ...CircuitPkg) (*Measurement, error) {
+	kinds, err := signalKinds(c.Template, pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	lib := NewEmptyLibrary()
+	defer lib.Burn()
+	reports, err := lib.Compile(append([]CircuitPkg{{
+		TargetVersion: "2.2.0",
+		Field:         "bn128",
+		Programs:      []Program{c.Main()},
+	}}, pkgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		if strings.EqualFold(report.Severity, "error") {
+			return nil, fmt.Errorf("%w %s: %s", ErrCompile, c, report.Message)
+		}
+	}
+
+	// inputs are irrelevant to the number of
+	// constraints & signals, missing ones default to 0
+	evaluation, err := lib.Evaluate([]byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		report = &Measurement{Circuit: c}
+		public = make(map[string]bool, len(c.Public))
+		// witness index -> symbol, witness 0 is the constant "one"
+		syms = append([]string{""}, evaluation.ConstrainedSyms()...)
+	)
+	for _, p := range c.Public {
+		public[p] = true
+	}
+	for _, sym := range syms[1:] {
+		path := strings.Split(reIndexes.ReplaceAllString(sym, ""), ".")
+		if len(path) != 2 {
+			report.Intermediates++
+			continue
+		}
+		switch kinds[path[1]] {
+		case "output":
+			report.Outputs++
+		case "input":
+			if public[path[1]] {
+				report.PublicInputs++
+			} else {
+				report.PrivateInputs++
+			}
+		default:
+			report.Intermediates++
+		}
+	}
+
+	constraints, err := constraintWitnesses(evaluation)
+	if err != nil {
+		return nil, err
+	}
+	report.Constraints = len(constraints)
+	if depth > 0 {
+		report.Components = breakdown(syms, constraints, depth)
+	}
+	return report, nil
+}
+
+// signalKinds maps the input & output signals
+// declared by template to "input" or "output"
+func signalKinds(template string, pkgs []CircuitPkg) (map[string]string, error) {
+	for _, pkg := range pkgs {
+		for _, p := range pkg.Programs {
+			src, ok := registry.Definitions(p.Src)[template]
+			if !ok || !strings.HasPrefix(strings.TrimSpace(src), "template") {
+				continue
+			}
+			kinds := make(map[string]string)
+			for _, m := range reSignals.FindAllStringSubmatch(src, -1) {
+				kind := m[1] + m[2]
+				for _, decl := range strings.Split(m[3], ",") {
+					name := strings.TrimSpace(decl)
+					if i := strings.IndexAny(name, "[<= "); i >= 0 {
+						name = name[:i]
+					}
+					if name != "" {
+						kinds[name] = kind
+					}
+				}
+			}
+			return kinds, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, template)
+}
+
+// evaluationJSON is the part of the evaluation JSON documented by veritas
+// (the format its circom FFI emits) holding the linear combinations
+// of every constraint as [witness, coefficient] terms
+type evaluationJSON struct {
+	Constraints *[]struct {
+		A *[][2]string `json:"a_constraints"`
+		B *[][2]string `json:"b_constraints"`
+		C *[][2]string `json:"c_constraints"`
+	} `json:"constraints"`
+}
+
+// constraintWitnesses returns the witnesses involved in every constraint.
+// The Evaluation interface doesn't expose the linear combinations,
+// they are read from the JSON encoding of the evaluation.
+// Errors if the encoding doesn't hold them rather than measuring nothing
+func constraintWitnesses(evaluation Evaluation) ([][]int, error) {
+	data, err := json.Marshal(evaluation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEvaluationLayout, err)
+	}
+	var decoded evaluationJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEvaluationLayout, err)
+	}
+	if decoded.Constraints == nil {
+		return nil, fmt.Errorf("%w: %T encodes no constraints", ErrEvaluationLayout, evaluation)
+	}
+	lcs := *decoded.Constraints
+	// the constraints must be the ones the public API reports on
+	if n := len(evaluation.SatisfiedConstraints()) + len(evaluation.UnSatisfiedConstraints()); len(lcs) != n {
+		return nil, fmt.Errorf("%w: %d constraints, %d evaluated", ErrEvaluationLayout, len(lcs), n)
+	}
+
+	out := make([][]int, len(lcs))
+	for i, lc := range lcs {
+		for _, terms := range []*[][2]string{lc.A, lc.B, lc.C} {
+			if terms == nil {
+				return nil, fmt.Errorf("%w: constraint %d misses a linear combination", ErrEvaluationLayout, i)
+			}
+			for _, term := range *terms {
+				w, err := strconv.Atoi(term[0])
+				if err != nil {
+					return nil, fmt.Errorf("%w: constraint %d: %w", ErrEvaluationLayout, i, err)
+				}
+				out[i] = append(out[i], w)
+			}
+		}
+	}
+	return out, nil
+}
+
+// component returns the normalised name & the instance path
+// of the component of sym up to depth, "" if sym belongs to main
+func component(sym string, depth int) (name string, instance string) {
+	path := strings.Split(sym, ".")
+	path = path[1 : len(path)-1]
+	if len(path) > depth {
+		path = path[:depth]
+	}
+	names := make([]string, len(path))
+	for i, p := range path {
+		names[i] = reAnonymous.ReplaceAllString(reIndexes.ReplaceAllString(p, ""), "")
+	}
+	return strings.Join(names, "."), strings.Join(path, ".")
+}
+
+func breakdown(syms []string, constraints [][]int, depth int) []Component {
+	var (
+		components = make(map[string]*Component)
+		instances  = make(map[string]map[string]bool)
+		get        = func(name string) *Component {
+			if _, ok := components[name]; !ok {
+				components[name] = &Component{Name: name}
+				instances[name] = make(map[string]bool)
+			}
+			return components[name]
+		}
+	)
+	for _, sym := range syms[1:] {
+		name, instance := component(sym, depth)
+		if name == "" {
+			continue
+		}
+		get(name).Signals++
+		instances[name][instance] = true
+	}
+	for _, witnesses := range constraints {
+		// smallest component containing every signal
+		var common []string
+		first := true
+		for _, w := range witnesses {
+			if w <= 0 || w >= len(syms) {
+				continue
+			}
+			_, instance := component(syms[w], depth)
+			path := strings.Split(instance, ".")
+			if first {
+				common, first = path, false
+				continue
+			}
+			n := 0
+			for n < len(common) && n < len(path) && common[n] == path[n] {
+				n++
+			}
+			common = common[:n]
+		}
+		if len(common) == 0 || common[0] == "" {
+			continue
+		}
+		name, _ := component("main."+strings.Join(common, ".")+".x", depth)
+		get(name).Constraints++
+	}
+
+	out := make([]Component, 0, len(components))
+	for name, c := range components {
+		c.Instances = len(instances[name])
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
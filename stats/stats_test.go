@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+func Test_Measure(t *testing.T) {
+	pkgs, err := privacypool.Bundle()
+	require.Nil(t, err)
+
+	m, err := Measure(Circuit{Template: "Num2Bits", Params: []int{4}}, 1, pkgs...)
+	require.Nil(t, err)
+	require.Equal(t, Counts{Constraints: 5, PrivateInputs: 1, Outputs: 4}, m.Counts)
+
+	m, err = Measure(Circuit{Template: "IsEqual", Public: []string{"in"}}, 1, pkgs...)
+	require.Nil(t, err)
+	require.Equal(t, 2, m.PublicInputs)
+	require.Equal(t, 1, m.Outputs)
+	require.Equal(t, 3, m.Constraints)
+	require.Len(t, m.Components, 1)
+	require.Equal(t, "isz", m.Components[0].Name)
+	require.Equal(t, 1, m.Components[0].Instances)
+	require.Equal(t, 2, m.Components[0].Signals)
+
+	_, err = Measure(Circuit{Template: "Unknown"}, 1, pkgs...)
+	require.True(t, errors.Is(err, ErrTemplateNotFound))
+
+	// evaluations without the expected layout aren't measured as empty
+	_, err = constraintWitnesses(struct{ Evaluation }{})
+	require.True(t, errors.Is(err, ErrEvaluationLayout))
+}
+
+func Test_Compare(t *testing.T) {
+	entry := Entry{
+		Circuit: Circuit{Template: "Num2Bits", Params: []int{4}},
+		Counts:  Counts{Constraints: 5, PrivateInputs: 1, Outputs: 4},
+	}
+	require.Nil(t, entry.Compare(Counts{Constraints: 4, PrivateInputs: 1, Outputs: 4}))
+
+	err := entry.Compare(Counts{Constraints: 7, PrivateInputs: 1, Outputs: 4})
+	require.True(t, errors.Is(err, ErrRegression))
+	require.Contains(t, err.Error(), "Num2Bits(4)")
+	require.Contains(t, err.Error(), "constraints: 7 > 5 (+2)")
+}
+
+// Fails when a change makes a circuit larger than its baseline,
+// update it with:
+//
+//	go run ./cmd/circuit-stats -baseline stats/baseline.json -update
+func Test_Baseline(t *testing.T) {
+	baseline, err := LoadBaseline("baseline.json")
+	require.Nil(t, err)
+	pkgs, err := privacypool.Bundle()
+	require.Nil(t, err)
+
+	for _, entry := range baseline {
+		m, err := Measure(entry.Circuit, 0, pkgs...)
+		require.Nil(t, err)
+		require.Nil(t, entry.Compare(m.Counts))
+		if m.Counts != entry.Counts {
+			t.Logf("%s shrank, consider updating the baseline: %+v", entry.Circuit, m.Counts)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package privacypool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+)
+
+var ErrInconsistentOutputs = errors.New("inconsistent PrivacyPool outputs")
+
+// PrivacyPoolOutputs holds the output signals of PrivacyPool,
+// existing commitments first then new commitments
+type PrivacyPoolOutputs struct {
+	NewNullRoot       []*big.Int
+	NewCommitmentRoot []*big.Int
+	NewCommitmentHash []*big.Int
+}
+
+// OutputError reports the output slot
+// (index in the output arrays) that is inconsistent
+type OutputError struct {
+	Slot     int
+	Existing bool
+	Reason   string
+}
+
+func (e *OutputError) Error() string {
+	kind := "new"
+	if e.Existing {
+		kind = "existing"
+	}
+	return fmt.Sprintf("%s commitment (slot %d): %s", kind, e.Slot, e.Reason)
+}
+
+func (e *OutputError) Unwrap() error {
+	return ErrInconsistentOutputs
+}
+
+// VerifyOutputs re-checks the outputs that the circuit leaves
+// to be verified against the public signals:
+//
+//   - existing commitments always output a nullRoot,
+//     their commitmentRoot & hash are both zero once spent,
+//     an invalid commitment outputs its hash & its commitmentRoot,
+//     which is zero if its ownership is invalid (see HandleExistingCommitment),
+//     so only a non-zero commitmentRoot without hash is inconsistent
+//   - new commitments with a valid ownership output a commitmentRoot
+//     matching newCiphertext & newCommitmentHash and no nullRoot,
+//     otherwise a zero commitmentRoot and a non-zero nullRoot
+//
+// Every inconsistent slot is reported as an *OutputError
+func VerifyOutputs(params PrivacyPoolParams, inputs *PrivacyPoolInputs, outputs *PrivacyPoolOutputs) error {
+	var (
+		nTotal = params.NExisting + params.NNew
+		errs   []error
+	)
+	for _, signal := range []struct {
+		name   string
+		values []*big.Int
+	}{
+		{"newNullRoot", outputs.NewNullRoot},
+		{"newCommitmentRoot", outputs.NewCommitmentRoot},
+		{"newCommitmentHash", outputs.NewCommitmentHash},
+	} {
+		if len(signal.values) != nTotal {
+			errs = append(errs, fmt.Errorf("%s: expected %d elements, got %d", signal.name, nTotal, len(signal.values)))
+		}
+	}
+	if len(inputs.NewCiphertext) != params.NNew {
+		errs = append(errs, fmt.Errorf("newCiphertext: expected %d elements, got %d", params.NNew, len(inputs.NewCiphertext)))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInconsistentOutputs, errors.Join(errs...))
+	}
+
+	isZero := func(v *big.Int) bool { return v == nil || v.Sign() == 0 }
+	for i := 0; i < params.NExisting; i++ {
+		var (
+			nullRoot = outputs.NewNullRoot[i]
+			root     = outputs.NewCommitmentRoot[i]
+			hash     = outputs.NewCommitmentHash[i]
+		)
+		if isZero(nullRoot) {
+			errs = append(errs, &OutputError{Slot: i, Existing: true, Reason: "newNullRoot is zero"})
+		}
+		if !isZero(root) && isZero(hash) {
+			errs = append(errs, &OutputError{Slot: i, Existing: true,
+				Reason: "newCommitmentHash is zero for a non-zero newCommitmentRoot"})
+		}
+	}
+	for i := 0; i < params.NNew; i++ {
+		var (
+			slot     = params.NExisting + i
+			nullRoot = outputs.NewNullRoot[slot]
+			root     = outputs.NewCommitmentRoot[slot]
+			hash     = outputs.NewCommitmentHash[slot]
+		)
+		if isZero(root) {
+			// invalid ownership nullifies the new commitment
+			if isZero(nullRoot) {
+				errs = append(errs, &OutputError{Slot: slot, Reason: "newNullRoot is zero for a nullified commitment"})
+			}
+			continue
+		}
+		if !isZero(nullRoot) {
+			errs = append(errs, &OutputError{Slot: slot, Reason: "newNullRoot is non-zero for a valid commitment"})
+		}
+		if isZero(hash) {
+			errs = append(errs, &OutputError{Slot: slot, Reason: "newCommitmentHash is zero"})
+			continue
+		}
+		if len(inputs.NewCiphertext[i]) != params.CipherLen {
+			errs = append(errs, &OutputError{Slot: slot, Reason: fmt.Sprintf(
+				"newCiphertext[%d]: expected %d elements, got %d", i, params.CipherLen, len(inputs.NewCiphertext[i]))})
+			continue
+		}
+		expected, err := core.ComputeCommitmentRoot(inputs.NewCiphertext[i], hash)
+		if err != nil {
+			return err
+		}
+		if expected.Cmp(root) != 0 {
+			errs = append(errs, &OutputError{Slot: slot,
+				Reason: fmt.Sprintf("newCommitmentRoot does not match newCiphertext[%d] & newCommitmentHash", i)})
+		}
+	}
+	return errors.Join(errs...)
+}
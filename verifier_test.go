@@ -0,0 +1,127 @@
+package privacypool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+	"github.com/0xBow-io/privacy-pool-veritas/harness"
+	. "github.com/0xBow-io/veritas"
+	"github.com/test-go/testify/require"
+)
+
+// evaluationOutputs reads the output signals of PrivacyPool
+func evaluationOutputs(t *testing.T, evaluation Evaluation, params PrivacyPoolParams) *PrivacyPoolOutputs {
+	outputs := &PrivacyPoolOutputs{}
+	for i := 0; i < params.NExisting+params.NNew; i++ {
+		outputs.NewNullRoot = append(outputs.NewNullRoot, harness.Signal(t, evaluation, fmt.Sprintf("main.newNullRoot[%d]", i)))
+		outputs.NewCommitmentRoot = append(outputs.NewCommitmentRoot, harness.Signal(t, evaluation, fmt.Sprintf("main.newCommitmentRoot[%d]", i)))
+		outputs.NewCommitmentHash = append(outputs.NewCommitmentHash, harness.Signal(t, evaluation, fmt.Sprintf("main.newCommitmentHash[%d]", i)))
+	}
+	return outputs
+}
+
+func Test_VerifyOutputs(t *testing.T) {
+	lib := compilePrivacyPool(t, "component main {public[scope, actualTreeDepth, context, externIO, existingStateRoot, newSaltPublicKey, newCiphertext]} = "+testParams.Instance()+";")
+	defer lib.Burn()
+
+	evaluate := func(inputs *PrivacyPoolInputs) *PrivacyPoolOutputs {
+		data, err := json.Marshal(inputs)
+		require.Nil(t, err)
+		evaluation, err := lib.Evaluate(data)
+		require.Nil(t, err)
+		require.Len(t, evaluation.UnSatisfiedConstraints(), 0)
+		return evaluationOutputs(t, evaluation, testParams)
+	}
+	slots := func(err error) []int {
+		var out []int
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var outputErr *OutputError
+			require.True(t, errors.As(e, &outputErr))
+			out = append(out, outputErr.Slot)
+		}
+		return out
+	}
+
+	builder, _, _ := newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120, 0})
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+	outputs := evaluate(inputs)
+	require.Nil(t, VerifyOutputs(testParams, inputs, outputs))
+
+	// tampered commitmentRoot of the first new commitment
+	tampered := *outputs
+	tampered.NewCommitmentRoot = append([]*big.Int{}, outputs.NewCommitmentRoot...)
+	tampered.NewCommitmentRoot[2] = new(big.Int).Add(outputs.NewCommitmentRoot[2], big.NewInt(1))
+	err = VerifyOutputs(testParams, inputs, &tampered)
+	require.True(t, errors.Is(err, ErrInconsistentOutputs))
+	require.Equal(t, []int{2}, slots(err))
+	require.Contains(t, err.Error(), "new commitment (slot 2): newCommitmentRoot does not match newCiphertext[0]")
+
+	// ciphertext swapped between new commitments
+	swapped := *inputs
+	swapped.NewCiphertext = [][]*big.Int{inputs.NewCiphertext[1], inputs.NewCiphertext[0]}
+	require.Equal(t, []int{2, 3}, slots(VerifyOutputs(testParams, &swapped, outputs)))
+
+	// nullRoot of a valid new commitment & a spent commitment without nullRoot
+	tampered = *outputs
+	tampered.NewNullRoot = append([]*big.Int{}, outputs.NewNullRoot...)
+	tampered.NewNullRoot[1] = big.NewInt(0)
+	tampered.NewNullRoot[3] = big.NewInt(1)
+	err = VerifyOutputs(testParams, inputs, &tampered)
+	require.Equal(t, []int{1, 3}, slots(err))
+
+	// a new commitment under another scope is nullified by the circuit
+	builder, _, _ = newTestInputs(t, testParams, [2]int64{0, 30}, []int64{100, 50}, []int64{120})
+	other := newTestCommitment(t, randomBelow(t, field.Modulus), 0)
+	inputs, err = builder.AddNewCommitment(other).Build()
+	require.Nil(t, err)
+	outputs = evaluate(inputs)
+	require.Equal(t, big.NewInt(0), outputs.NewCommitmentRoot[3])
+	require.NotEqual(t, big.NewInt(0), outputs.NewNullRoot[3])
+	require.Nil(t, VerifyOutputs(testParams, inputs, outputs))
+
+	// an existing commitment under another scope is invalidated by the circuit
+	// with a zero commitmentRoot but a non-zero hash
+	scope := randomBelow(t, field.Modulus)
+	foreign := newTestCommitment(t, randomBelow(t, field.Modulus), 100)
+	owned := newTestCommitment(t, scope, 50)
+	tree := newTestStateTree(t, foreign, owned)
+	builder = NewPrivacyPoolInputsBuilder(testParams).
+		Scope(scope).
+		Context(randomBelow(t, field.Modulus)).
+		ExternIO(big.NewInt(0), big.NewInt(30)).
+		StateTree(tree.Root(), tree.Depth())
+	for _, c := range []*core.Commitment{foreign, owned} {
+		proof, err := tree.GenerateProof(tree.IndexOf(c.CommitmentRoot), testParams.MaxTreeDepth)
+		require.Nil(t, err)
+		builder.AddExistingCommitment(c, proof)
+	}
+	inputs, err = builder.
+		AddNewCommitment(newTestCommitment(t, scope, 20)).
+		AddNewCommitment(newTestCommitment(t, scope, 0)).
+		Build()
+	require.Nil(t, err)
+	outputs = evaluate(inputs)
+	require.Equal(t, big.NewInt(0), outputs.NewCommitmentRoot[0])
+	require.NotEqual(t, big.NewInt(0), outputs.NewCommitmentHash[0])
+	require.Nil(t, VerifyOutputs(testParams, inputs, outputs))
+
+	// a commitmentRoot without hash
+	tampered = *outputs
+	tampered.NewCommitmentRoot = append([]*big.Int{}, outputs.NewCommitmentRoot...)
+	tampered.NewCommitmentRoot[0] = big.NewInt(1)
+	tampered.NewCommitmentHash = append([]*big.Int{}, outputs.NewCommitmentHash...)
+	tampered.NewCommitmentHash[0] = big.NewInt(0)
+	require.Equal(t, []int{0}, slots(VerifyOutputs(testParams, inputs, &tampered)))
+
+	// dimensions
+	outputs.NewNullRoot = outputs.NewNullRoot[:3]
+	err = VerifyOutputs(testParams, inputs, outputs)
+	require.True(t, errors.Is(err, ErrInconsistentOutputs))
+	require.Contains(t, err.Error(), "newNullRoot: expected 4 elements, got 3")
+}
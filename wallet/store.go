@@ -0,0 +1,194 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+)
+
+var ErrNoteNotFound = errors.New("note not found")
+
+// Note is a commitment owned by the wallet
+// alongside its leaf index in the state tree
+type Note struct {
+	Index      int              `json:"index"`
+	Commitment *core.Commitment `json:"commitment"`
+	// Spent is set once the nullRoot of the commitment is published
+	Spent bool `json:"spent"`
+}
+
+// Store persists the notes of a wallet,
+// notes are keyed by their commitmentRoot (the state tree leaf)
+type Store interface {
+	// Put inserts or replaces a note
+	Put(note *Note) error
+	// Get returns the note of commitmentRoot or ErrNoteNotFound
+	Get(commitmentRoot *big.Int) (*Note, error)
+	// Notes returns every note ordered by Index
+	Notes() ([]*Note, error)
+}
+
+// MemoryStore keeps the notes in memory
+type MemoryStore struct {
+	mu    sync.RWMutex
+	notes map[string]*Note
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{notes: make(map[string]*Note)}
+}
+
+func (s *MemoryStore) Put(note *Note) error {
+	if err := checkNote(note); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := *note
+	s.notes[noteKey(note.Commitment.CommitmentRoot)] = &n
+	return nil
+}
+
+func (s *MemoryStore) Get(commitmentRoot *big.Int) (*Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	note, ok := s.notes[noteKey(commitmentRoot)]
+	if !ok {
+		return nil, ErrNoteNotFound
+	}
+	n := *note
+	return &n, nil
+}
+
+func (s *MemoryStore) Notes() ([]*Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	notes := make([]*Note, 0, len(s.notes))
+	for _, note := range s.notes {
+		n := *note
+		notes = append(notes, &n)
+	}
+	sortNotes(notes)
+	return notes, nil
+}
+
+// FileStore keeps the notes in memory and
+// writes them all as JSON to a file on every Put
+type FileStore struct {
+	// serializes Puts from the snapshot of the notes
+	// to the rename of the wallet file
+	mu     sync.Mutex
+	path   string
+	memory *MemoryStore
+}
+
+// OpenFileStore loads the notes from path,
+// the file is created on the first Put if it doesn't exist
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, memory: NewMemoryStore()}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var notes []*Note
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("wallet file %s: %w", path, err)
+	}
+	for _, note := range notes {
+		if err := s.memory.Put(note); err != nil {
+			return nil, fmt.Errorf("wallet file %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Put writes the notes with note to the file
+// & only then keeps note in memory,
+// a failed write leaves the store unchanged
+func (s *FileStore) Put(note *Note) error {
+	if err := checkNote(note); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notes, err := s.memory.Notes()
+	if err != nil {
+		return err
+	}
+	key, replaced := noteKey(note.Commitment.CommitmentRoot), false
+	for i, n := range notes {
+		if noteKey(n.Commitment.CommitmentRoot) == key {
+			notes[i], replaced = note, true
+			break
+		}
+	}
+	if !replaced {
+		notes = append(notes, note)
+	}
+	sortNotes(notes)
+	if err := s.write(notes); err != nil {
+		return err
+	}
+	return s.memory.Put(note)
+}
+
+func (s *FileStore) write(notes []*Note) error {
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so that
+	// the wallet file is never partially written
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileStore) Get(commitmentRoot *big.Int) (*Note, error) {
+	return s.memory.Get(commitmentRoot)
+}
+
+func (s *FileStore) Notes() ([]*Note, error) {
+	return s.memory.Notes()
+}
+
+func checkNote(note *Note) error {
+	if note == nil || note.Commitment == nil || note.Commitment.CommitmentRoot == nil {
+		return errors.New("note commitment is required")
+	}
+	if note.Index < 0 {
+		return fmt.Errorf("note index %d is negative", note.Index)
+	}
+	return nil
+}
+
+func noteKey(commitmentRoot *big.Int) string {
+	if commitmentRoot == nil {
+		return ""
+	}
+	return commitmentRoot.Text(16)
+}
+
+func sortNotes(notes []*Note) {
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Index < notes[j].Index })
+}
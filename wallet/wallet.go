@@ -0,0 +1,161 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+)
+
+var (
+	ErrNoteSpent     = errors.New("note is spent")
+	ErrNoteNotInTree = errors.New("note is not in the state tree")
+)
+
+// Wallet tracks the spent & spendable notes of a key
+type Wallet struct {
+	store Store
+}
+
+func New(store Store) *Wallet {
+	return &Wallet{store: store}
+}
+
+// Receive stores the commitment at index in the state tree as unspent,
+// receiving a note twice keeps its spent status
+func (w *Wallet) Receive(index int, c *core.Commitment) error {
+	if c == nil {
+		return errors.New("commitment is required")
+	}
+	note := &Note{Index: index, Commitment: c}
+	if existing, err := w.store.Get(c.CommitmentRoot); err == nil {
+		note.Spent = existing.Spent
+	} else if !errors.Is(err, ErrNoteNotFound) {
+		return err
+	}
+	return w.store.Put(note)
+}
+
+// ReceiveScanned stores the results of a core.Scanner,
+// the scanned Index must be the state tree index
+func (w *Wallet) ReceiveScanned(results []core.ScanResult) error {
+	for _, r := range results {
+		if err := w.Receive(r.Index, r.Commitment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Spend marks the notes of the published nullRoots as spent
+// and returns the number of notes newly spent
+func (w *Wallet) Spend(nullRoots ...*big.Int) (int, error) {
+	notes, err := w.store.Notes()
+	if err != nil {
+		return 0, err
+	}
+	published := make(map[string]bool, len(nullRoots))
+	for _, nullRoot := range nullRoots {
+		if nullRoot != nil && nullRoot.Sign() != 0 {
+			published[nullRoot.Text(16)] = true
+		}
+	}
+	spent := 0
+	for _, note := range notes {
+		if note.Spent || !published[note.Commitment.NullRoot.Text(16)] {
+			continue
+		}
+		note.Spent = true
+		if err := w.store.Put(note); err != nil {
+			return spent, err
+		}
+		spent++
+	}
+	return spent, nil
+}
+
+// Spendable returns the unspent notes with a non-zero value ordered by Index
+func (w *Wallet) Spendable() ([]*Note, error) {
+	notes, err := w.store.Notes()
+	if err != nil {
+		return nil, err
+	}
+	spendable := notes[:0]
+	for _, note := range notes {
+		if !note.Spent && note.Commitment.Value.Sign() != 0 {
+			spendable = append(spendable, note)
+		}
+	}
+	return spendable, nil
+}
+
+// Balance is the sum of the spendable values
+func (w *Wallet) Balance() (*big.Int, error) {
+	notes, err := w.Spendable()
+	if err != nil {
+		return nil, err
+	}
+	balance := big.NewInt(0)
+	for _, note := range notes {
+		balance.Add(balance, note.Commitment.Value)
+	}
+	return balance, nil
+}
+
+// ExistingInputs holds the private signals of an
+// existing commitment in the PrivacyPool inputs
+type ExistingInputs struct {
+	PrivateKey    *big.Int    `json:"privateKey"`
+	Nonce         *big.Int    `json:"nonce"`
+	SaltPublicKey [2]*big.Int `json:"exSaltPublicKey"`
+	Ciphertext    []*big.Int  `json:"exCiphertext"`
+	Index         *big.Int    `json:"exIndex"`
+	Siblings      []*big.Int  `json:"exSiblings"`
+	// ActualDepth is the actualTreeDepth of the membership proof
+	ActualDepth int `json:"actualTreeDepth"`
+}
+
+// Proof returns the membership proof of the note in the state tree
+func (n *Note) Proof(tree *merkletree.LeanIMT, maxTreeDepth int) (*merkletree.LeanIMTProof, error) {
+	if n.Spent {
+		return nil, fmt.Errorf("%w: index %d", ErrNoteSpent, n.Index)
+	}
+	if n.Index >= tree.Size() || tree.Leaves()[n.Index].Cmp(n.Commitment.CommitmentRoot) != 0 {
+		return nil, fmt.Errorf("%w: index %d", ErrNoteNotInTree, n.Index)
+	}
+	return tree.GenerateProof(n.Index, maxTreeDepth)
+}
+
+// Existing returns the inputs to spend the note as an
+// existing commitment of a proof against the state tree
+func (n *Note) Existing(tree *merkletree.LeanIMT, maxTreeDepth int) (*ExistingInputs, error) {
+	proof, err := n.Proof(tree, maxTreeDepth)
+	if err != nil {
+		return nil, err
+	}
+	c := n.Commitment
+	return &ExistingInputs{
+		PrivateKey:    c.PrivateKey,
+		Nonce:         c.Nonce,
+		SaltPublicKey: [2]*big.Int{c.SaltPublicKey.X, c.SaltPublicKey.Y},
+		Ciphertext:    c.Ciphertext,
+		Index:         big.NewInt(int64(proof.LeafIndex)),
+		Siblings:      proof.Siblings,
+		ActualDepth:   proof.ActualDepth,
+	}, nil
+}
+
+// AddExisting appends the notes as existing commitments to the builder
+func AddExisting(builder *privacypool.PrivacyPoolInputsBuilder, tree *merkletree.LeanIMT, maxTreeDepth int, notes ...*Note) error {
+	for _, note := range notes {
+		proof, err := note.Proof(tree, maxTreeDepth)
+		if err != nil {
+			return err
+		}
+		builder.AddExistingCommitment(note.Commitment, proof)
+	}
+	return nil
+}
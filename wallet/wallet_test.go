@@ -0,0 +1,202 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	privacypool "github.com/0xBow-io/privacy-pool-veritas"
+	"github.com/0xBow-io/privacy-pool-veritas/common/babyjub"
+	"github.com/0xBow-io/privacy-pool-veritas/common/field"
+	"github.com/0xBow-io/privacy-pool-veritas/common/merkletree"
+	"github.com/0xBow-io/privacy-pool-veritas/core"
+	"github.com/test-go/testify/require"
+)
+
+var testParams = privacypool.PrivacyPoolParams{MaxTreeDepth: 4, CipherLen: 7, TupleLen: 4, NExisting: 2, NNew: 2}
+
+func randomBelow(t *testing.T, max *big.Int) *big.Int {
+	v, err := rand.Int(rand.Reader, max)
+	require.Nil(t, err)
+	return v
+}
+
+// newTestPool publishes commitments of values owned by privateKey
+// among commitments of other keys, returning the state tree & the stream
+func newTestPool(t *testing.T, scope, privateKey, nonce *big.Int, values ...int64) (*merkletree.LeanIMT, []core.PublishedCommitment) {
+	var (
+		tree      = merkletree.NewLeanIMT()
+		published []core.PublishedCommitment
+	)
+	publish := func(c *core.Commitment) {
+		published = append(published, core.PublishedCommitment{
+			Index:         tree.Size(),
+			SaltPublicKey: c.SaltPublicKey,
+			Ciphertext:    c.Ciphertext,
+		})
+		require.Nil(t, tree.Insert(c.CommitmentRoot))
+	}
+	for _, v := range values {
+		other, err := core.NewCommitment(scope, big.NewInt(v), randomBelow(t, babyjub.SubOrder), randomBelow(t, babyjub.SubOrder), nonce)
+		require.Nil(t, err)
+		publish(other)
+		owned, err := core.NewCommitment(scope, big.NewInt(v), privateKey, randomBelow(t, babyjub.SubOrder), nonce)
+		require.Nil(t, err)
+		publish(owned)
+	}
+	return tree, published
+}
+
+func Test_Wallet(t *testing.T) {
+	var (
+		scope      = randomBelow(t, field.Modulus)
+		privateKey = randomBelow(t, babyjub.SubOrder)
+		nonce      = big.NewInt(0)
+		path       = filepath.Join(t.TempDir(), "wallet.json")
+	)
+	tree, published := newTestPool(t, scope, privateKey, nonce, 100, 0, 50, 20)
+
+	results, err := (&core.Scanner{
+		PrivateKey: privateKey,
+		Scope:      scope,
+		TupleLen:   core.TupleLen,
+		Nonces:     core.FixedNonces(nonce),
+	}).ScanAll(context.Background(), published)
+	require.Nil(t, err)
+	require.Len(t, results, 4)
+
+	store, err := OpenFileStore(path)
+	require.Nil(t, err)
+	w := New(store)
+	require.Nil(t, w.ReceiveScanned(results))
+
+	// void notes aren't spendable
+	spendable, err := w.Spendable()
+	require.Nil(t, err)
+	require.Len(t, spendable, 3)
+	require.Equal(t, []int{1, 5, 7}, []int{spendable[0].Index, spendable[1].Index, spendable[2].Index})
+	balance, err := w.Balance()
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(170), balance)
+
+	// spend the first note, unknown nullRoots are ignored
+	spent, err := w.Spend(spendable[0].Commitment.NullRoot, randomBelow(t, field.Modulus))
+	require.Nil(t, err)
+	require.Equal(t, 1, spent)
+	_, err = spendable[0].Proof(tree, testParams.MaxTreeDepth)
+	require.Nil(t, err)
+
+	// receiving a spent note again keeps it spent
+	require.Nil(t, w.Receive(results[0].Index, results[0].Commitment))
+
+	// reopen the file store
+	store, err = OpenFileStore(path)
+	require.Nil(t, err)
+	w = New(store)
+	balance, err = w.Balance()
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(70), balance)
+	note, err := store.Get(results[0].Commitment.CommitmentRoot)
+	require.Nil(t, err)
+	require.True(t, note.Spent)
+	require.Equal(t, results[0].Commitment.NullRoot, note.Commitment.NullRoot)
+	_, err = note.Proof(tree, testParams.MaxTreeDepth)
+	require.True(t, errors.Is(err, ErrNoteSpent))
+	_, err = store.Get(big.NewInt(1))
+	require.True(t, errors.Is(err, ErrNoteNotFound))
+
+	// fill the existing commitments of a proof
+	spendable, err = w.Spendable()
+	require.Nil(t, err)
+	existing, err := spendable[0].Existing(tree, testParams.MaxTreeDepth)
+	require.Nil(t, err)
+	require.Equal(t, spendable[0].Commitment.Ciphertext, existing.Ciphertext)
+	require.Equal(t, tree.Depth(), existing.ActualDepth)
+
+	builder := privacypool.NewPrivacyPoolInputsBuilder(testParams).
+		Scope(scope).
+		Context(randomBelow(t, field.Modulus)).
+		ExternIO(big.NewInt(0), big.NewInt(70)).
+		StateTree(tree.Root(), tree.Depth())
+	require.Nil(t, AddExisting(builder, tree, testParams.MaxTreeDepth, spendable...))
+	for i := 0; i < testParams.NNew; i++ {
+		c, err := core.NewCommitment(scope, big.NewInt(0), privateKey, randomBelow(t, babyjub.SubOrder), nonce)
+		require.Nil(t, err)
+		builder.AddNewCommitment(c)
+	}
+	inputs, err := builder.Build()
+	require.Nil(t, err)
+	require.Equal(t, existing.Siblings, inputs.ExSiblings[0])
+	require.Equal(t, existing.Index, inputs.ExIndex[0])
+
+	// a note of another tree
+	other, _ := newTestPool(t, scope, privateKey, nonce, 1)
+	_, err = spendable[0].Proof(other, testParams.MaxTreeDepth)
+	require.True(t, errors.Is(err, ErrNoteNotInTree))
+}
+
+func Test_MemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	require.NotNil(t, store.Put(&Note{}))
+
+	c, err := core.NewCommitment(big.NewInt(1), big.NewInt(1), randomBelow(t, babyjub.SubOrder), randomBelow(t, babyjub.SubOrder), big.NewInt(0))
+	require.Nil(t, err)
+	require.Nil(t, store.Put(&Note{Index: 3, Commitment: c}))
+	require.Nil(t, store.Put(&Note{Index: 3, Commitment: c, Spent: true}))
+	notes, err := store.Notes()
+	require.Nil(t, err)
+	require.Len(t, notes, 1)
+	require.True(t, notes[0].Spent)
+
+	// notes are copies
+	notes[0].Spent = false
+	note, err := store.Get(c.CommitmentRoot)
+	require.Nil(t, err)
+	require.True(t, note.Spent)
+}
+
+func Test_FileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wallet")
+	store, err := OpenFileStore(filepath.Join(dir, "notes.json"))
+	require.Nil(t, err)
+
+	// a failed write leaves the store unchanged
+	c, err := core.NewCommitment(big.NewInt(1), big.NewInt(1), randomBelow(t, babyjub.SubOrder), randomBelow(t, babyjub.SubOrder), big.NewInt(0))
+	require.Nil(t, err)
+	require.NotNil(t, store.Put(&Note{Index: 0, Commitment: c}))
+	_, err = store.Get(c.CommitmentRoot)
+	require.True(t, errors.Is(err, ErrNoteNotFound))
+
+	// concurrent Puts all reach the file
+	require.Nil(t, os.Mkdir(dir, 0o700))
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, 8)
+	)
+	for i := range errs {
+		c, err := core.NewCommitment(big.NewInt(1), big.NewInt(int64(i)), randomBelow(t, babyjub.SubOrder), randomBelow(t, babyjub.SubOrder), big.NewInt(0))
+		require.Nil(t, err)
+		wg.Add(1)
+		go func(i int, c *core.Commitment) {
+			defer wg.Done()
+			errs[i] = store.Put(&Note{Index: i, Commitment: c})
+		}(i, c)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.Nil(t, err)
+	}
+	reopened, err := OpenFileStore(filepath.Join(dir, "notes.json"))
+	require.Nil(t, err)
+	notes, err := reopened.Notes()
+	require.Nil(t, err)
+	require.Len(t, notes, len(errs))
+	for i, note := range notes {
+		require.Equal(t, i, note.Index)
+	}
+}
@@ -0,0 +1,40 @@
+package privacypool
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PrivacyPoolWithdrawInputs holds every input signal of PrivacyPoolWithdraw,
+// i.e. PrivacyPoolInputs without new commitments
+// and with externIO[1] as the externOutput
+type PrivacyPoolWithdrawInputs struct {
+	PrivacyPoolInputs
+}
+
+// BuildWithdraw is Build for PrivacyPoolWithdraw(maxTreeDepth, cipherLen, tupleLen, nExisting),
+// the builder params must have no new commitment
+// and externIO[0] is left unset (or zero)
+func (b *PrivacyPoolInputsBuilder) BuildWithdraw() (*PrivacyPoolWithdrawInputs, error) {
+	if b.params.NNew != 0 {
+		return nil, fmt.Errorf("%w: withdraw expects no new commitment, params have %d", ErrInvalidInputs, b.params.NNew)
+	}
+	if v := b.inputs.ExternIO[0]; v != nil && v.Sign() != 0 {
+		return nil, fmt.Errorf("%w: externIO[0] must be zero for a withdraw", ErrInvalidInputs)
+	}
+	b.ExternIO(big.NewInt(0), b.inputs.ExternIO[1])
+	inputs, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &PrivacyPoolWithdrawInputs{PrivacyPoolInputs: *inputs}, nil
+}
+
+// MarshalJSON encodes the inputs as the input JSON of PrivacyPoolWithdraw
+// (see PrivacyPoolInputs.MarshalJSON)
+func (in PrivacyPoolWithdrawInputs) MarshalJSON() ([]byte, error) {
+	return marshalSignals(in.PrivacyPoolInputs,
+		[]string{"externIO", "newSaltPublicKey", "newCiphertext"},
+		map[string][]string{"externOutput": toDecimals(in.ExternIO[1])},
+	)
+}